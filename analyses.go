@@ -0,0 +1,101 @@
+package densify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reasons an analysis was not used for an account/cluster
+const (
+	SkipReasonPolicy     = "policy does not match"
+	SkipReasonIncomplete = "analysis has not completed"
+	SkipReasonSuperseded = "a more recent analysis exists for the same account/cluster"
+)
+
+// an analysis that matched the account/cluster in the query but was not used, and why
+type SkippedAnalysis struct {
+	Analysis DensifyAnalysis
+	Reason   string
+}
+
+// the analysis status values that mean the analysis has completed
+var completedAnalysisStatuses = []string{"completed", "complete"}
+
+// returns true if the analysis has completed at least once
+func (a *DensifyAnalysis) isCompleted() bool {
+	if a.AnalysisCompleted > 0 {
+		return true
+	}
+	// some Densify versions return a status value rather than a completion date; "Incomplete" or "Not Completed" must not match
+	status := strings.TrimSpace(a.AnalysisStatus)
+	for i := 0; i < len(completedAnalysisStatuses); i++ {
+		if strings.EqualFold(status, completedAnalysisStatuses[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the account/cluster an analysis belongs to; used to find the most recent analysis of duplicates
+func (a *DensifyAnalysis) accountOrClusterId() string {
+	if a.AccountId != "" {
+		return strings.ToLower(a.AccountId)
+	}
+	if a.AccountName != "" {
+		return strings.ToLower(a.AccountName)
+	}
+	return strings.ToLower(a.AnalysisName)
+}
+
+// narrow down the matching analyses by the query's policy name or instance id, completed status and freshness; returns the analyses to use and the ones that were skipped
+func (q *DensifyAPIQuery) selectAnalyses(analyses []DensifyAnalysis) ([]DensifyAnalysis, []SkippedAnalysis) {
+	selected := []DensifyAnalysis{}
+	skipped := []SkippedAnalysis{}
+	for i := 0; i < len(analyses); i++ {
+		if q.PolicyName != "" && strings.ToLower(analyses[i].PolicyName) != q.PolicyName {
+			skipped = append(skipped, SkippedAnalysis{Analysis: analyses[i], Reason: SkipReasonPolicy})
+			continue
+		}
+		if q.PolicyInstanceId != "" && strings.ToLower(analyses[i].PolicyInstanceId) != q.PolicyInstanceId {
+			skipped = append(skipped, SkippedAnalysis{Analysis: analyses[i], Reason: SkipReasonPolicy})
+			continue
+		}
+		if q.CompletedOnly && !analyses[i].isCompleted() {
+			skipped = append(skipped, SkippedAnalysis{Analysis: analyses[i], Reason: SkipReasonIncomplete})
+			continue
+		}
+		selected = append(selected, analyses[i])
+	}
+	if !q.LatestAnalysisOnly {
+		return selected, skipped
+	}
+
+	// keep only the most recent analysis for each account/cluster
+	latest := map[string]int{} // account/cluster > index in selected
+	for i := 0; i < len(selected); i++ {
+		id := selected[i].accountOrClusterId()
+		j, ok := latest[id]
+		if !ok || selected[i].AnalysisCompleted > selected[j].AnalysisCompleted {
+			latest[id] = i
+		}
+	}
+	retSelected := []DensifyAnalysis{}
+	for i := 0; i < len(selected); i++ {
+		if latest[selected[i].accountOrClusterId()] == i {
+			retSelected = append(retSelected, selected[i])
+		} else {
+			skipped = append(skipped, SkippedAnalysis{Analysis: selected[i], Reason: SkipReasonSuperseded})
+		}
+	}
+	return retSelected, skipped
+}
+
+// output the skipped analyses, one per line (for useful error messages only)
+func skippedAnalysesStr(skipped []SkippedAnalysis) string {
+	var sb strings.Builder
+	for i := 0; i < len(skipped); i++ {
+		a := skipped[i].Analysis
+		sb.WriteString(fmt.Sprintf("%s (%s, policy: %s): %s\n", a.AnalysisName, a.AnalysisId, a.PolicyName, skipped[i].Reason))
+	}
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+package densify
+
+import "testing"
+
+func TestAnalysisIsCompleted(t *testing.T) {
+	tests := []struct {
+		name     string
+		analysis DensifyAnalysis
+		want     bool
+	}{
+		{"completion date", DensifyAnalysis{AnalysisCompleted: 1700000000000}, true},
+		{"completed status", DensifyAnalysis{AnalysisStatus: "Completed"}, true},
+		{"complete status", DensifyAnalysis{AnalysisStatus: " COMPLETE "}, true},
+		{"incomplete status", DensifyAnalysis{AnalysisStatus: "Incomplete"}, false},
+		{"not completed status", DensifyAnalysis{AnalysisStatus: "Not Completed"}, false},
+		{"completing status", DensifyAnalysis{AnalysisStatus: "completing"}, false},
+		{"no status", DensifyAnalysis{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.analysis.isCompleted(); got != tt.want {
+				t.Errorf("isCompleted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectAnalyses(t *testing.T) {
+	analyses := []DensifyAnalysis{
+		{AnalysisId: "a1", AccountId: "111", PolicyName: "Prod", PolicyInstanceId: "P1", AnalysisCompleted: 100},
+		{AnalysisId: "a2", AccountId: "111", PolicyName: "Prod", PolicyInstanceId: "P1", AnalysisCompleted: 200},
+		{AnalysisId: "a3", AccountId: "222", PolicyName: "Dev", PolicyInstanceId: "P2", AnalysisStatus: "Incomplete"},
+		{AnalysisId: "a4", AccountId: "333", PolicyName: "Prod", PolicyInstanceId: "P3", AnalysisStatus: "Completed"},
+	}
+	tests := []struct {
+		name     string
+		query    DensifyAPIQuery
+		selected []string
+		skipped  map[string]string
+	}{
+		{"no filters", DensifyAPIQuery{}, []string{"a1", "a2", "a3", "a4"}, map[string]string{}},
+		{"policy name", DensifyAPIQuery{PolicyName: "prod"}, []string{"a1", "a2", "a4"}, map[string]string{"a3": SkipReasonPolicy}},
+		{"policy instance id", DensifyAPIQuery{PolicyInstanceId: "p3"}, []string{"a4"},
+			map[string]string{"a1": SkipReasonPolicy, "a2": SkipReasonPolicy, "a3": SkipReasonPolicy}},
+		{"completed only", DensifyAPIQuery{CompletedOnly: true}, []string{"a1", "a2", "a4"}, map[string]string{"a3": SkipReasonIncomplete}},
+		{"latest only", DensifyAPIQuery{LatestAnalysisOnly: true}, []string{"a2", "a3", "a4"}, map[string]string{"a1": SkipReasonSuperseded}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, skipped := tt.query.selectAnalyses(analyses)
+			if len(selected) != len(tt.selected) {
+				t.Fatalf("selected %d analyses, want %d: %v", len(selected), len(tt.selected), selected)
+			}
+			for i := 0; i < len(selected); i++ {
+				if selected[i].AnalysisId != tt.selected[i] {
+					t.Errorf("selected[%d] = %s, want %s", i, selected[i].AnalysisId, tt.selected[i])
+				}
+			}
+			if len(skipped) != len(tt.skipped) {
+				t.Fatalf("skipped %d analyses, want %d: %v", len(skipped), len(tt.skipped), skipped)
+			}
+			for i := 0; i < len(skipped); i++ {
+				if want := tt.skipped[skipped[i].Analysis.AnalysisId]; skipped[i].Reason != want {
+					t.Errorf("%s skipped for '%s', want '%s'", skipped[i].Analysis.AnalysisId, skipped[i].Reason, want)
+				}
+			}
+		})
+	}
+}
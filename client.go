@@ -35,19 +35,23 @@ type DensifyClient struct {
 	ApiUserName    string
 	ApiPassword    string
 	ApiToken       string
-	ApiTokenExpiry int64
+	ApiTokenExpiry EpochMillis
 
 	// Densify Query
 	Query *DensifyAPIQuery
 
+	// return an UnknownFieldsError when the Densify API returns fields that the models don't declare; useful for monitoring API contract changes
+	StrictDecoding bool
+
 	// other values to store in-between API calls
 
-	AnalysisIds []string // store the analysis ids that make up the account or cluster (which can be separated across multiple analyses)
+	AnalysisIds     []string          // store the analysis ids that make up the account or cluster (which can be separated across multiple analyses)
+	SkippedAnalyses []SkippedAnalysis // the matching analyses that were not used because of the query's analysis selection (policy, status, latest)
 }
 
 type AuthResponse struct {
 	ApiToken string
-	Expires  int64
+	Expires  EpochMillis
 	Status   int
 	Message  string
 }
@@ -97,8 +101,9 @@ func (c *DensifyClient) ConfigureQuery(query *DensifyAPIQuery) error {
 	// let's lowercase all the values first
 	query.setValuesToLowercase()
 
-	// validate the query is valid
-	err := query.validate()
+	// validate the query is valid; the full (single recommendation) validation happens in GetDensifyRecommendation so that
+	// account, cluster or namespace-wide queries can also be configured
+	err := query.validateAnalysis()
 	if err != nil {
 		return err
 	}
@@ -106,6 +111,7 @@ func (c *DensifyClient) ConfigureQuery(query *DensifyAPIQuery) error {
 	c.Query = query
 	// reset other fields
 	c.AnalysisIds = []string{}
+	c.SkippedAnalyses = []SkippedAnalysis{}
 
 	return nil // no error
 }
@@ -196,6 +202,11 @@ func (c *DensifyClient) GetAccountOrCluster() (*[]DensifyAnalysis, error) {
 	if err != nil {
 		return nil, errors.New("JSON decode error: " + err.Error())
 	}
+	if c.StrictDecoding {
+		if err = checkUnknownFields(analyses); err != nil {
+			return nil, err
+		}
+	}
 	retAnalyses := []DensifyAnalysis{}
 	retErr := ""
 	accountName := strings.ToLower(c.Query.AccountName)
@@ -251,7 +262,13 @@ func (c *DensifyClient) GetAccountOrCluster() (*[]DensifyAnalysis, error) {
 		retErr += uniqueListOfAccounts.CsvStrWithNewLine()
 		return nil, errors.New(retErr)
 	}
+	// narrow down duplicate analyses by policy, status and freshness
+	retAnalyses, c.SkippedAnalyses = c.Query.selectAnalyses(retAnalyses)
+	if len(retAnalyses) == 0 {
+		return nil, fmt.Errorf("all matching analyses were skipped:\n%s", skippedAnalysesStr(c.SkippedAnalyses))
+	}
 	// set the analysis ids as well
+	c.AnalysisIds = []string{}
 	for i := 0; i < len(retAnalyses); i++ {
 		c.AnalysisIds = append(c.AnalysisIds, retAnalyses[i].AnalysisId)
 	}
@@ -285,53 +302,61 @@ func (c *DensifyClient) GetDensifyRecommendation() (*DensifyRecommendation, erro
 		}
 		return emptyObj, err
 	}
-	// go through the list of recommendations and look for the entity name provided
+	// resolve the controller type from the namespace and pod name if it wasn't provided in the query
+	controllerType := c.Query.K8sControllerType
+	if isKubernetesRequest && controllerType == "" {
+		controllerType, err = c.Query.resolveControllerType(recos)
+		if err != nil {
+			if c.Query.SkipErrors {
+				return emptyObj, nil
+			}
+			return emptyObj, err
+		}
+	}
+
+	// cloud instance recommendation; look up the entity by the identifier in the query
+	if !isKubernetesRequest {
+		found, ok := NewRecommendationIndex(recos).Lookup(c.Query.SystemIdKind, c.Query.SystemName)
+		if ok {
+			reco := *found
+			// check if the ApprovedType needs a fallback
+			if reco.ApprovedType == "" {
+				reco.ApprovedType = c.Query.FallbackInstance
+			}
+			c.Query.applyMaturityPolicy(&reco)
+			return &reco, nil
+		}
+	}
+
+	// go through the list of recommendations and look for the kubernetes workload provided
+	queryKey := NewWorkloadKey(c.Query.K8sCluster, c.Query.K8sNamespace, controllerType, c.Query.K8sPodName)
+	queryContainer := normalizeKeyPart(c.Query.K8sContainerName)
 	count := len(*recos)
 	var reco DensifyRecommendation
-	for i := 0; i < count; i++ {
-		if isKubernetesRequest { // kubernetes recommendation
-			// check the namespace and pod name as well
-			recoName := strings.ToLower((*recos)[i].Container)
-			recoNamespace := strings.ToLower((*recos)[i].Namespace)
-			recoPodName := strings.ToLower((*recos)[i].PodService)
-			recoControllerType := strings.ToLower((*recos)[i].ControllerType)
-			if recoNamespace == c.Query.K8sNamespace && recoControllerType == c.Query.K8sControllerType && recoPodName == c.Query.K8sPodName {
-				if c.Query.K8sContainerName != "" {
-					// if a container name was provided, only return that one container, rather than the whole pod (which could have multiple containers)
-					if recoName == c.Query.K8sContainerName {
-						reco = (*recos)[i]
-						// also manually add the container recommendation(s) to the pod list of containers
-						reco.AddContainerToPod(&(*recos)[i])
-						return &reco, nil
-					}
-				} else {
-					// no container_name was provided in the query, so let's add to the pod list of containers
-					if reco.isEmpty() {
-						reco = (*recos)[i]
-					}
-					// also manually add the container recommendation(s) to the internal list
+	for i := 0; i < count && isKubernetesRequest; i++ {
+		// kubernetes recommendation; check the namespace, controller type and pod name (the query cluster can be part of the analysis name, so it's not compared)
+		recoKey := (*recos)[i].ContainerKey()
+		if recoKey.sameWorkloadInCluster(queryKey) {
+			if queryContainer != "" {
+				// if a container name was provided, only return that one container, rather than the whole pod (which could have multiple containers)
+				if recoKey.Container == queryContainer {
+					reco = (*recos)[i]
+					// also manually add the container recommendation(s) to the pod list of containers
 					reco.AddContainerToPod(&(*recos)[i])
-					// if there are multiple containers within the pod, let's clear out the container name
-					if len(reco.Containers) > 1 {
-						reco.Name = reco.PodService // change the (container) name > pod name
-						reco.Container = ""         // clear the container name
-					}
-				}
-			}
-		} else { // cloud instance recommendation
-			recoName := strings.ToLower((*recos)[i].Name)
-			if recoName == c.Query.SystemName {
-				reco = (*recos)[i]
-				// check if the ApprovedType needs a fallback
-				if reco.ApprovedType == "" {
-					reco.ApprovedType = c.Query.FallbackInstance
+					c.Query.setContainerFallbacks(&reco)
+					c.Query.applyMaturityPolicy(&reco)
+					return &reco, nil
 				}
-				return &reco, nil
+			} else {
+				// no container_name was provided in the query, so let's add to the pod list of containers
+				reco.addContainerToWorkload(&(*recos)[i])
 			}
 		}
 	}
 	// return the recommendation if it exists
 	if isKubernetesRequest && reco.Namespace != "" {
+		c.Query.setContainerFallbacks(&reco)
+		c.Query.applyMaturityPolicy(&reco)
 		return &reco, nil
 	}
 
@@ -340,8 +365,11 @@ func (c *DensifyClient) GetDensifyRecommendation() (*DensifyRecommendation, erro
 	}
 	// return a different error msg if it's a cloud vs k8s query
 	if isKubernetesRequest {
-		return emptyObj, fmt.Errorf(`could not find a Densify recommendation for pod (%s) in namespace (%s), controller (%s), container name (%s)`, c.Query.K8sPodName, c.Query.K8sNamespace, c.Query.K8sControllerType, c.Query.K8sContainerName)
+		return emptyObj, fmt.Errorf(`could not find a Densify recommendation for pod (%s) in namespace (%s), controller (%s), container name (%s)`, c.Query.K8sPodName, c.Query.K8sNamespace, controllerType, c.Query.K8sContainerName)
 	} else {
+		if c.Query.SystemIdKind != "" && c.Query.SystemIdKind != SystemIdName {
+			return emptyObj, fmt.Errorf("could not find a Densify recommendation with %s: %s", c.Query.SystemIdKind, c.Query.SystemName)
+		}
 		return emptyObj, fmt.Errorf("could not find a Densify recommendation named: %s", c.Query.SystemName)
 	}
 }
@@ -394,6 +422,11 @@ func (c *DensifyClient) GetDensifyRecommendations() (*[]DensifyRecommendation, e
 		if err != nil {
 			return nil, errors.New("JSON decode error: " + err.Error())
 		}
+		if c.StrictDecoding {
+			if err = checkUnknownFields(recos); err != nil {
+				return nil, err
+			}
+		}
 
 		// add some additional parameters that are not returned in the API call
 		count := len(recos)
@@ -406,7 +439,7 @@ func (c *DensifyClient) GetDensifyRecommendations() (*[]DensifyRecommendation, e
 			recos[i].AnalysisTechnology = c.Query.AnalysisTechnology
 			recos[i].AccountId = c.Query.AccountNumber
 			recos[i].AccountName = c.Query.AccountName
-			recos[i].ApprovedType = recos[i].RecommendedType
+			recos[i].ApprovedType = recos[i].GetApprovedType()
 		}
 		// now we copy the recommendations into the retRecos slice
 		retRecos = append(retRecos, recos...)
@@ -462,6 +495,11 @@ func (c *DensifyClient) LoadDensifyGuardrailsAllInstances(reco *DensifyRecommend
 	if instGov.Message != "" {
 		return fmt.Errorf("encountered an error: %s", instGov.Message)
 	}
+	if c.StrictDecoding {
+		if err = checkUnknownFields(instGov); err != nil {
+			return err
+		}
+	}
 
 	// add it to the current recommendation
 	reco.Guardrails = instGov
@@ -469,76 +507,63 @@ func (c *DensifyClient) LoadDensifyGuardrailsAllInstances(reco *DensifyRecommend
 }
 
 func (c *DensifyClient) IsTokenExpired() bool {
-	now := time.Now().UnixNano() / int64(time.Millisecond)
-	return now >= c.ApiTokenExpiry
+	return !time.Now().Before(c.ApiTokenExpiry.Time())
 }
 
+// Convert the recommendations to a terraform map variable named densify_recommendations, ex. for a .tfvars file. See HCLExporter for
+// selecting the fields and keys.
 func (c *DensifyClient) ConvertRecommendationsToTF(recommendations *[]DensifyRecommendation) string {
-	return c.ConvertRecommendationsToTFWithVarName(recommendations, "densify_recommendations")
+	tf, err := c.ConvertRecommendationsToTFVar(recommendations, DefaultTFVarName)
+	if err != nil {
+		// the default fields, keys and variable name always export
+		return ""
+	}
+	return tf
 }
 
+// Convert the recommendations to a terraform map variable, the same as ConvertRecommendationsToTFVar, except that if the variable name
+// isn't a valid terraform identifier, densify_recommendations is used instead.
+//
+// Deprecated: use ConvertRecommendationsToTFVar, which returns an error for an invalid variable name.
 func (c *DensifyClient) ConvertRecommendationsToTFWithVarName(recommendations *[]DensifyRecommendation, tfVarName string) string {
-	var sb strings.Builder
-	sb.WriteString(tfVarName + " = {")
-	count := len(*recommendations)
-	newline := "\n"
-	for i := 0; i < count; i++ {
-		reco := (*recommendations)[i]
-		// if reco.AnalysisType == "cloud" {
-		sb.WriteString(fmt.Sprintf(`  "%s" {%s`, reco.Name, newline))
-		sb.WriteString(fmt.Sprintf(`    analysisType="%s"%s`, reco.AnalysisType, newline))
-		sb.WriteString(fmt.Sprintf(`    analysisTechnology="%s"%s`, reco.AnalysisTechnology, newline))
-		sb.WriteString(fmt.Sprintf(`    accountIdRef="%s"%s`, reco.AccountIdRef, newline))
-		sb.WriteString(fmt.Sprintf(`    region="%s"%s`, reco.Region, newline))
-		sb.WriteString(fmt.Sprintf(`    serviceType="%s"%s`, reco.ServiceType, newline))
-		sb.WriteString(fmt.Sprintf(`    currentType="%s"%s`, reco.CurrentType, newline))
-		sb.WriteString(fmt.Sprintf(`    recommendationType="%s"%s`, reco.RecommendationType, newline))
-		sb.WriteString(fmt.Sprintf(`    currentType="%s"%s`, reco.CurrentType, newline))
-		sb.WriteString(fmt.Sprintf(`    recommendedType="%s"%s`, reco.RecommendedType, newline))
-		sb.WriteString(fmt.Sprintf(`    powerState="%s"%s`, reco.PowerState, newline))
-		sb.WriteString(fmt.Sprintf(`    predictedUptime="%s"%s`, ConvertFloatToStr(reco.PredictedUptime), newline))
-		sb.WriteString(fmt.Sprintf(`    implementationMethod="%s"%s`, reco.ImplementationMethod, newline))
-		sb.WriteString(fmt.Sprintf(`    approvalTypecurrentType="%s"%s`, reco.ApprovalType, newline))
-		sb.WriteString(fmt.Sprintf(`    savingsEstimate="%s"%s`, ConvertFloatToStr(reco.SavingsEstimate), newline))
-		sb.WriteString(fmt.Sprintf(`    effortEstimate="%s"%s`, reco.EffortEstimate, newline))
-		sb.WriteString(fmt.Sprintf(`    densifyPolicy="%s"%s`, reco.DensifyPolicy, newline))
-		// } else
-		if reco.AnalysisType == "containers" {
-			sb.WriteString(fmt.Sprintf(`  "%s" {%s`, reco.Name, newline))
-			sb.WriteString(fmt.Sprintf(`    cluster="%s"%s`, reco.Cluster, newline))
-			sb.WriteString(fmt.Sprintf(`    container="%s"%s`, reco.Container, newline))
-			sb.WriteString(fmt.Sprintf(`    controllerType="%s"%s`, reco.ControllerType, newline))
-			sb.WriteString(fmt.Sprintf(`    namespace="%s"%s`, reco.Namespace, newline))
-			sb.WriteString(fmt.Sprintf(`    podService="%s"%s`, reco.PodService, newline))
-			sb.WriteString(fmt.Sprintf(`    estimatedSavings="%s"%s`, ConvertFloatToStr(reco.EstimatedSavings), newline))
-			sb.WriteString(fmt.Sprintf(`    totalNetSavings="%s"%s`, ConvertFloatToStr(reco.TotalNetSavings), newline))
-			sb.WriteString(fmt.Sprintf(`    displayName="%s"%s`, reco.DisplayName, newline))
-			sb.WriteString(fmt.Sprintf(`    currentCount="%d"%s`, reco.CurrentCount, newline))
-			sb.WriteString(fmt.Sprintf(`    currentCpuRequest="%d"%s`, reco.CurrentCpuRequest, newline))
-			sb.WriteString(fmt.Sprintf(`    currentCpuLimit="%d"%s`, reco.CurrentCpuLimit, newline))
-			sb.WriteString(fmt.Sprintf(`    currentMemRequest="%d"%s`, reco.CurrentMemRequest, newline))
-			sb.WriteString(fmt.Sprintf(`    currentMemLimit="%d"%s`, reco.CurrentMemLimit, newline))
-			sb.WriteString(fmt.Sprintf(`    recommendedCpuRequest="%d"%s`, reco.RecommendedCpuRequest, newline))
-			sb.WriteString(fmt.Sprintf(`    recommendedCpuLimit="%d"%s`, reco.RecommendedCpuLimit, newline))
-			sb.WriteString(fmt.Sprintf(`    recommendedMemRequest="%d"%s`, reco.RecommendedMemRequest, newline))
-			sb.WriteString(fmt.Sprintf(`    recommendedMemLimit="%d"%s`, reco.RecommendedMemLimit, newline))
-			sb.WriteString(fmt.Sprintf(`    runningHours="%d"%s`, reco.RunningHours, newline))
-		}
-		sb.WriteString(`  }` + newline)
+	opts := ExportOptions{VarName: tfVarName}
+	if _, err := opts.varName(); err != nil {
+		tfVarName = DefaultTFVarName
+	}
+	tf, err := c.ConvertRecommendationsToTFVar(recommendations, tfVarName)
+	if err != nil {
+		// the default fields and keys always export, so only an invalid variable name can fail, and it was replaced above
+		return ""
 	}
-	sb.WriteString("}")
+	return tf
+}
 
-	return sb.String()
+// Convert the recommendations to a terraform map variable (a .tfvars attribute, or an attribute in a locals block), keyed by system name
+// for cloud recommendations and cluster/namespace/controllerType/podService/container for kubernetes. The map is sorted by key; CPU is in
+// millicores, memory is in MiB and costs are numbers. Returns an error if the variable name isn't a valid terraform identifier; an empty
+// variable name uses densify_recommendations.
+func (c *DensifyClient) ConvertRecommendationsToTFVar(recommendations *[]DensifyRecommendation, tfVarName string) (string, error) {
+	var sb strings.Builder
+	e := HCLExporter{Options: ExportOptions{VarName: tfVarName}}
+	if err := e.Export(&sb, recommendations); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
 }
 
 func (c *DensifyClient) returnEmptyRecommendationWithFallback() *DensifyRecommendation {
 	const emptyRecoType = "Client Error - using fallback values"
+	// the fallback values are validated when the query is configured, so invalid values are left empty here
+	fallback, err := c.Query.getFallbackResources()
+	if err != nil {
+		fallback = &fallbackResources{}
+	}
 	containers := []DensifyContainerRecommendation{{
 		Container:          c.Query.K8sContainerName,
-		FallbackCpuRequest: c.Query.FallbackCPURequest,
-		FallbackCpuLimit:   c.Query.FallbackCPULimit,
-		FallbackMemRequest: c.Query.FallbackMemRequest,
-		FallbackMemLimit:   c.Query.FallbackMemLimit,
+		FallbackCpuRequest: fallback.CpuRequest,
+		FallbackCpuLimit:   fallback.CpuLimit,
+		FallbackMemRequest: fallback.MemRequest,
+		FallbackMemLimit:   fallback.MemLimit,
 		RecommendationType: emptyRecoType,
 	}}
 	return &DensifyRecommendation{
@@ -0,0 +1,239 @@
+package densify
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the CSV column with each row's key (see ExportKeyStrategy)
+const csvKeyColumn = "key"
+
+// Exports the recommendations as CSV (or TSV), one row per recommendation and one row per container of pod-level recommendations,
+// sorted by key. The columns are the options' fields, plus "key" for the row's key; by default every field except containers is a
+// column, and the ones that don't apply to a row (ex. container sizing for a cloud recommendation) are left empty. Numbers are
+// written without grouping and with a "." decimal separator, so spreadsheets read them the same in every locale, and text starting
+// with "=", "+", "-" or "@" is prefixed with a single quote so spreadsheets don't evaluate it as a formula.
+type CSVExporter struct {
+	Options   ExportOptions
+	Delimiter rune // ',' for CSV or '\t' for TSV; defaults to ','
+}
+
+// returns the columns; a nil field is the key column
+func (e *CSVExporter) columns() ([]string, []*exportField, error) {
+	names := e.Options.Fields
+	if len(names) == 0 {
+		names = []string{csvKeyColumn}
+		for i := 0; i < len(exportFields); i++ {
+			if exportFields[i].name != "containers" {
+				names = append(names, exportFields[i].name)
+			}
+		}
+	}
+	fieldNames := []string{}
+	for i := 0; i < len(names); i++ {
+		if names[i] == "containers" {
+			return nil, nil, fmt.Errorf("containers can't be a CSV column; pod-level recommendations get a row per container")
+		}
+		if names[i] != csvKeyColumn {
+			fieldNames = append(fieldNames, names[i])
+		}
+	}
+	fieldOpts := ExportOptions{Fields: fieldNames}
+	fields, err := fieldOpts.fields()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make([]*exportField, 0, len(names))
+	for i := 0; i < len(names); i++ {
+		if names[i] == csvKeyColumn {
+			columns = append(columns, nil)
+			continue
+		}
+		for j := 0; j < len(fields); j++ {
+			if fields[j].name == names[i] {
+				columns = append(columns, &fields[j])
+				break
+			}
+		}
+	}
+	return names, columns, nil
+}
+
+// the first characters of a cell that make spreadsheets read it as a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// returns text as a CSV cell; text that a spreadsheet would read as a formula is prefixed with a single quote, so names from the Densify
+// API are never evaluated
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune(csvFormulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// returns a number as a CSV cell; NaN and infinity are left empty
+func csvNumber(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// returns a value as a CSV cell; numbers aren't text, so negative amounts aren't prefixed
+func csvCell(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return csvText(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return csvNumber(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case Money:
+		return v.Amount(), nil
+	default:
+		return "", fmt.Errorf("unsupported CSV value type %T", value)
+	}
+}
+
+// a row before it's formatted
+type csvRow struct {
+	key       string
+	reco      *DensifyRecommendation
+	container *exportObject // the container's values, for a container row of a pod-level recommendation
+}
+
+func (e *CSVExporter) Export(w io.Writer, recos *[]DensifyRecommendation) error {
+	names, columns, err := e.columns()
+	if err != nil {
+		return err
+	}
+
+	rows := []csvRow{}
+	if recos != nil {
+		for i := 0; i < len(*recos); i++ {
+			reco := &(*recos)[i]
+			key, err := e.Options.KeyBy.key(reco)
+			if err != nil {
+				return err
+			}
+			if len(reco.Containers) == 0 {
+				rows = append(rows, csvRow{key: key, reco: reco})
+				continue
+			}
+			for j := 0; j < len(reco.Containers); j++ {
+				c := &reco.Containers[j]
+				containerKey := key
+				if e.Options.KeyBy == KeyByDefault || e.Options.KeyBy == KeyByWorkloadKey {
+					containerKey = reco.WorkloadKey().ContainerKey(c.Container).String()
+				}
+				rows = append(rows, csvRow{key: containerKey, reco: reco, container: c.exportObject()})
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].key < rows[j].key
+	})
+
+	writer := csv.NewWriter(w)
+	if e.Delimiter != 0 {
+		writer.Comma = e.Delimiter
+	}
+	if err := writer.Write(names); err != nil {
+		return err
+	}
+	selected := len(e.Options.Fields) > 0
+	for i := 0; i < len(rows); i++ {
+		record := make([]string, len(columns))
+		for j := 0; j < len(columns); j++ {
+			column := columns[j]
+			switch {
+			case column == nil:
+				record[j] = csvText(rows[i].key)
+			case !selected && !column.appliesTo(rows[i].reco):
+				record[j] = ""
+			default:
+				record[j], err = csvCell(rows[i].cell(column))
+				if err != nil {
+					return fmt.Errorf("column '%s': %v", column.name, err)
+				}
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// returns the row's value for a column; container rows use the container's value where it has one
+func (row *csvRow) cell(column *exportField) interface{} {
+	if row.container != nil {
+		for i := 0; i < len(row.container.attributes); i++ {
+			if row.container.attributes[i].key == column.name {
+				return row.container.attributes[i].value
+			}
+		}
+	}
+	return column.value(row.reco)
+}
+
+// the guardrails export columns
+var guardrailsCSVColumns = []string{
+	"entityId", "name", "currentType", "targetType", "blendedScore", "compatibility", "incompatibilityReason", "percentOptimalCost",
+	"catalogCost", "numCpus", "memory", "generation", "effortEstimate",
+}
+
+// Exports the guardrails targets of the recommendations as CSV (or TSV), one row per entity and target instance type, in the order they
+// were loaded (see LoadDensifyGuardrailsAllInstances). The catalog cost is hourly, with four decimals, and incompatibility reasons are
+// separated by "; ".
+type GuardrailsCSVExporter struct {
+	Delimiter rune // ',' for CSV or '\t' for TSV; defaults to ','
+}
+
+func (e *GuardrailsCSVExporter) Export(w io.Writer, recos *[]DensifyRecommendation) error {
+	writer := csv.NewWriter(w)
+	if e.Delimiter != 0 {
+		writer.Comma = e.Delimiter
+	}
+	if err := writer.Write(guardrailsCSVColumns); err != nil {
+		return err
+	}
+	for i := 0; recos != nil && i < len(*recos); i++ {
+		reco := &(*recos)[i]
+		targets := reco.Guardrails.Targets
+		for j := 0; j < len(targets); j++ {
+			t := &targets[j]
+			record := []string{
+				csvText(reco.EntityId),
+				csvText(reco.Name),
+				csvText(reco.CurrentType),
+				csvText(t.InstanceType),
+				strconv.Itoa(t.BlendedScore),
+				csvText(string(t.Compatibility)),
+				csvText(strings.Join(t.IncompatibilityReason, "; ")),
+				csvNumber(float64(t.PercentOptimalCost)),
+				t.CatalogCost.Format(4),
+				strconv.FormatInt(t.NumCPUs, 10),
+				csvNumber(float64(t.Memory)),
+				strconv.Itoa(t.Generation),
+				csvText(string(t.EffortEstimate)),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,134 @@
+package densify
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   string
+		hasErr bool
+	}{
+		{"nil", nil, "", false},
+		{"text", "web", "web", false},
+		{"formula", "=HYPERLINK(\"x\")", "'=HYPERLINK(\"x\")", false},
+		{"plus", "+1", "'+1", false},
+		{"minus", "-cmd", "'-cmd", false},
+		{"at", "@SUM(A1)", "'@SUM(A1)", false},
+		{"tab", "\t=1", "'\t=1", false},
+		{"equals inside", "a=b", "a=b", false},
+		{"int", int64(-5), "-5", false},
+		{"float", 1234567.5, "1234567.5", false},
+		{"nan", math.NaN(), "", false},
+		{"bool", true, "true", false},
+		{"negative money", Money{Micros: -20000000}, "-20.00", false},
+		{"unsupported", []interface{}{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := csvCell(tt.value)
+			if (err != nil) != tt.hasErr {
+				t.Fatalf("csvCell(%v) error = %v, want error %v", tt.value, err, tt.hasErr)
+			}
+			if got != tt.want {
+				t.Errorf("csvCell(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVExporter(t *testing.T) {
+	savings, _ := ParseMoney("-3.5", "", PeriodMonthly)
+	recos := []DensifyRecommendation{
+		{Name: "=cmd|' /C calc'!A0", CurrentType: "m5.large", SavingsEstimate: savings},
+		{Name: "api, \"v2\"", CurrentType: "t3.large"},
+		{Cluster: "prod", Namespace: "shop", ControllerType: "deployment", PodService: "cart", Containers: []DensifyContainerRecommendation{
+			{Container: "web", CurrentCpuRequest: 500},
+			{Container: "app", CurrentCpuRequest: 250},
+		}},
+	}
+	tests := []struct {
+		name      string
+		exporter  CSVExporter
+		delimiter rune
+		rows      [][]string
+	}{
+		{"selected fields", CSVExporter{Options: ExportOptions{Fields: []string{"key", "name", "currentType", "savingsEstimate", "currentCpuRequest"}}}, ',', [][]string{
+			{"key", "name", "currentType", "savingsEstimate", "currentCpuRequest"},
+			{"'=cmd|' /C calc'!A0", "'=cmd|' /C calc'!A0", "m5.large", "-3.50", "0"},
+			{"api, \"v2\"", "api, \"v2\"", "t3.large", "0.00", "0"},
+			{"prod/shop/deployment/cart/app", "", "", "0.00", "250"},
+			{"prod/shop/deployment/cart/web", "", "", "0.00", "500"},
+		}},
+		{"tsv without a key", CSVExporter{Options: ExportOptions{Fields: []string{"currentType", "currentCpuRequest"}}, Delimiter: '\t'}, '\t', [][]string{
+			{"currentType", "currentCpuRequest"}, {"m5.large", "0"}, {"t3.large", "0"}, {"", "250"}, {"", "500"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.exporter.Export(&buf, &recos); err != nil {
+				t.Fatalf("Export() error: %v", err)
+			}
+			reader := csv.NewReader(&buf)
+			reader.Comma = tt.delimiter
+			rows, err := reader.ReadAll()
+			if err != nil {
+				t.Fatalf("ReadAll() error: %v", err)
+			}
+			if len(rows) != len(tt.rows) {
+				t.Fatalf("got %d rows, want %d: %q", len(rows), len(tt.rows), rows)
+			}
+			for i := 0; i < len(rows); i++ {
+				if strings.Join(rows[i], "|") != strings.Join(tt.rows[i], "|") {
+					t.Errorf("row %d = %q, want %q", i, rows[i], tt.rows[i])
+				}
+			}
+		})
+	}
+
+	// by default, fields that don't apply to a row are left empty
+	var buf bytes.Buffer
+	if err := (&CSVExporter{}).Export(&buf, &recos); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	rows, _ := csv.NewReader(&buf).ReadAll()
+	header := strings.Join(rows[0], ",")
+	if !strings.HasPrefix(header, "key,") || strings.Contains(header, "containers") {
+		t.Errorf("header = %s, want key first and no containers", header)
+	}
+
+	if err := (&CSVExporter{Options: ExportOptions{Fields: []string{"containers"}}}).Export(&buf, &recos); err == nil {
+		t.Errorf("Export() with a containers column didn't return an error")
+	}
+}
+
+func TestGuardrailsCSVExporter(t *testing.T) {
+	recos := []DensifyRecommendation{{EntityId: "e1", Name: "@web", CurrentType: "m5.large", Guardrails: DensifyGuardrails{Targets: []DensifyGuardrailsTarget{
+		{InstanceType: "m5.xlarge", BlendedScore: 90, Compatibility: CompatibilityOK, CatalogCost: Money{Micros: 192000}, NumCPUs: 4, Memory: 16},
+		{InstanceType: "t3.large", Compatibility: CompatibilityTechnicallyIncompatible, IncompatibilityReason: []string{"-cpu", "storage"}},
+	}}}}
+	var buf bytes.Buffer
+	if err := (&GuardrailsCSVExporter{}).Export(&buf, &recos); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3: %q", len(rows), rows)
+	}
+	if rows[1][1] != "'@web" || rows[1][3] != "m5.xlarge" || rows[1][8] != "0.1920" || rows[1][10] != "16" {
+		t.Errorf("row 1 = %q", rows[1])
+	}
+	if rows[2][6] != "'-cpu; storage" {
+		t.Errorf("incompatibilityReason = %q, want '-cpu; storage", rows[2][6])
+	}
+}
@@ -0,0 +1,197 @@
+package densify
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the kind of change between two recommendation snapshots
+type ChangeKind string
+
+const (
+	ChangeAdded           ChangeKind = "added"           // the recommendation is new
+	ChangeRemoved         ChangeKind = "removed"         // the recommendation disappeared
+	ChangeRecommendedType ChangeKind = "recommendedType" // the recommended instance type changed
+	ChangeContainerSizing ChangeKind = "containerSizing" // a recommended container request or limit changed
+	ChangeSavings         ChangeKind = "savings"         // the (monthly) savings changed
+	ChangeApproval        ChangeKind = "approval"        // the approval type changed
+)
+
+// the order changes are listed in for the same recommendation
+var changeKindOrder = map[ChangeKind]int{
+	ChangeAdded:           0,
+	ChangeRemoved:         1,
+	ChangeRecommendedType: 2,
+	ChangeContainerSizing: 3,
+	ChangeApproval:        4,
+	ChangeSavings:         5,
+}
+
+// a single change between two recommendation snapshots
+type RecommendationChange struct {
+	Kind         ChangeKind `json:"kind"`
+	Key          string     `json:"key"`             // the entity id (cloud), or cluster/namespace/controllerType/podService/container (kubernetes)
+	Name         string     `json:"name"`            // the system name, or cluster/namespace/controllerType/podService/container for kubernetes
+	Field        string     `json:"field,omitempty"` // the field that changed, ex. recommendedCpuRequest
+	Old          string     `json:"old,omitempty"`
+	New          string     `json:"new,omitempty"`
+	SavingsDelta Money      `json:"savingsDelta"` // the change in monthly savings (new - old); only set for added, removed and savings changes
+}
+
+// the changes between two recommendation snapshots
+type RecommendationDiff struct {
+	Changes      []RecommendationChange `json:"changes"` // sorted by key, then kind
+	SavingsDelta Money                  `json:"savingsDelta"`
+}
+
+// returns the key used to match a recommendation between snapshots
+func (r *DensifyRecommendation) diffKey() string {
+	if r.isKubernetes() {
+		return r.ContainerKey().String()
+	}
+	if r.EntityId != "" {
+		return r.EntityId
+	}
+	return strings.ToLower(r.Name)
+}
+
+// Compare two snapshots of (container-level) recommendations, ex. last week's and this week's GetDensifyRecommendations. Cloud recommendations
+// are matched by entity id and kubernetes recommendations by cluster, namespace, controller type, pod and container.
+func DiffRecommendations(oldRecos *[]DensifyRecommendation, newRecos *[]DensifyRecommendation) *RecommendationDiff {
+	index := func(recos *[]DensifyRecommendation) map[string]*DensifyRecommendation {
+		m := map[string]*DensifyRecommendation{}
+		if recos == nil {
+			return m
+		}
+		for i := 0; i < len(*recos); i++ {
+			key := (*recos)[i].diffKey()
+			if m[key] == nil {
+				m[key] = &(*recos)[i]
+			}
+		}
+		return m
+	}
+	oldIndex := index(oldRecos)
+	newIndex := index(newRecos)
+
+	diff := RecommendationDiff{Changes: []RecommendationChange{}}
+	for key, n := range newIndex {
+		o, found := oldIndex[key]
+		if !found {
+			diff.Changes = append(diff.Changes, RecommendationChange{
+				Kind:         ChangeAdded,
+				Key:          key,
+				Name:         n.summaryName(),
+				New:          string(n.RecommendationType),
+				SavingsDelta: n.MonthlySavings(),
+			})
+			diff.SavingsDelta = diff.SavingsDelta.Add(n.MonthlySavings())
+			continue
+		}
+		diff.Changes = append(diff.Changes, diffRecommendation(key, o, n)...)
+		diff.SavingsDelta = diff.SavingsDelta.Add(n.MonthlySavings()).Sub(o.MonthlySavings())
+	}
+	for key, o := range oldIndex {
+		if _, found := newIndex[key]; !found {
+			diff.Changes = append(diff.Changes, RecommendationChange{
+				Kind:         ChangeRemoved,
+				Key:          key,
+				Name:         o.summaryName(),
+				Old:          string(o.RecommendationType),
+				SavingsDelta: Money{}.Sub(o.MonthlySavings()),
+			})
+			diff.SavingsDelta = diff.SavingsDelta.Sub(o.MonthlySavings())
+		}
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		a, b := diff.Changes[i], diff.Changes[j]
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		if a.Kind != b.Kind {
+			return changeKindOrder[a.Kind] < changeKindOrder[b.Kind]
+		}
+		return a.Field < b.Field
+	})
+	return &diff
+}
+
+// returns the changes between two snapshots of the same recommendation
+func diffRecommendation(key string, o *DensifyRecommendation, n *DensifyRecommendation) []RecommendationChange {
+	changes := []RecommendationChange{}
+	change := func(kind ChangeKind, field string, oldValue string, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, RecommendationChange{Kind: kind, Key: key, Name: n.summaryName(), Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	change(ChangeRecommendedType, "recommendedType", o.RecommendedType, n.RecommendedType)
+	change(ChangeContainerSizing, "recommendedCpuRequest", o.RecommendedCpuRequest.String(), n.RecommendedCpuRequest.String())
+	change(ChangeContainerSizing, "recommendedCpuLimit", o.RecommendedCpuLimit.String(), n.RecommendedCpuLimit.String())
+	change(ChangeContainerSizing, "recommendedMemRequest", o.RecommendedMemRequest.String(), n.RecommendedMemRequest.String())
+	change(ChangeContainerSizing, "recommendedMemLimit", o.RecommendedMemLimit.String(), n.RecommendedMemLimit.String())
+	change(ChangeApproval, "approvalType", string(o.ApprovalType), string(n.ApprovalType))
+
+	// the same amount in another currency is a change too (zero is zero in any currency), and then the currencies are listed
+	oldSavings, newSavings := o.MonthlySavings(), n.MonthlySavings()
+	currencyChanged := !oldSavings.IsZero() && !newSavings.IsZero() && oldSavings.CurrencyCode() != newSavings.CurrencyCode()
+	if oldSavings.Micros != newSavings.Micros || currencyChanged {
+		savings := RecommendationChange{
+			Kind:         ChangeSavings,
+			Key:          key,
+			Name:         n.summaryName(),
+			Field:        "savings",
+			Old:          oldSavings.Amount(),
+			New:          newSavings.Amount(),
+			SavingsDelta: newSavings.Sub(oldSavings),
+		}
+		if currencyChanged {
+			savings.Old = oldSavings.String()
+			savings.New = newSavings.String()
+		}
+		changes = append(changes, savings)
+	}
+	return changes
+}
+
+// returns true if there are no changes
+func (d *RecommendationDiff) IsEmpty() bool {
+	return len(d.Changes) == 0
+}
+
+// returns the number of changes of a kind
+func (d *RecommendationDiff) Count(kind ChangeKind) int {
+	count := 0
+	for i := 0; i < len(d.Changes); i++ {
+		if d.Changes[i].Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// Render the changes as text, one per line: "+" added, "-" removed and "~" changed, followed by the total savings change.
+func (d *RecommendationDiff) Text() string {
+	var sb strings.Builder
+	for i := 0; i < len(d.Changes); i++ {
+		c := d.Changes[i]
+		switch c.Kind {
+		case ChangeAdded:
+			sb.WriteString(fmt.Sprintf("+ %s: %s, savings %s\n", c.Name, c.New, c.SavingsDelta))
+		case ChangeRemoved:
+			sb.WriteString(fmt.Sprintf("- %s: %s, savings %s\n", c.Name, c.Old, c.SavingsDelta))
+		default:
+			sb.WriteString(fmt.Sprintf("~ %s: %s %s > %s\n", c.Name, c.Field, c.Old, c.New))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("%d change(s), savings change: %s\n", len(d.Changes), d.SavingsDelta))
+	return sb.String()
+}
+
+// Render the changes as indented JSON.
+func (d *RecommendationDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
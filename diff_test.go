@@ -0,0 +1,150 @@
+package densify
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func diffTestMoney(amount string, currency string) Money {
+	m, _ := ParseMoney(amount, currency, PeriodMonthly)
+	return m
+}
+
+func TestDiffRecommendations(t *testing.T) {
+	oldRecos := []DensifyRecommendation{
+		{Name: "web", EntityId: "e1", RecommendationType: "Downsize", RecommendedType: "m5.large", ApprovalType: ApprovalNotApproved, SavingsEstimate: diffTestMoney("30", "")},
+		{Name: "db", EntityId: "e2", RecommendationType: "Modernize", SavingsEstimate: diffTestMoney("50", "")},
+		{Name: "api", EntityId: "e3", RecommendationType: "Terminate", SavingsEstimate: diffTestMoney("10", "")},
+		{Cluster: "prod", Namespace: "shop", ControllerType: "deployment", PodService: "cart", Container: "app",
+			RecommendedCpuRequest: 250, RecommendedMemLimit: 512, EstimatedSavings: diffTestMoney("5", "")},
+	}
+	newRecos := []DensifyRecommendation{
+		{Name: "web", EntityId: "e1", RecommendationType: "Downsize", RecommendedType: "m5.xlarge", ApprovalType: ApprovalAny, SavingsEstimate: diffTestMoney("20", "")},
+		{Name: "db", EntityId: "e2", RecommendationType: "Modernize", SavingsEstimate: diffTestMoney("50", "")},
+		{Name: "cache", EntityId: "e0", RecommendationType: "Downsize", SavingsEstimate: diffTestMoney("15", "")},
+		{Cluster: "Prod", Namespace: "shop", ControllerType: "Deployment", PodService: "cart", Container: "app",
+			RecommendedCpuRequest: 500, RecommendedMemLimit: 1024, EstimatedSavings: diffTestMoney("5", "")},
+	}
+
+	diff := DiffRecommendations(&oldRecos, &newRecos)
+	want := []RecommendationChange{
+		{Kind: ChangeAdded, Key: "e0", Name: "cache", New: "Downsize", SavingsDelta: diffTestMoney("15", "")},
+		{Kind: ChangeRecommendedType, Key: "e1", Name: "web", Field: "recommendedType", Old: "m5.large", New: "m5.xlarge"},
+		{Kind: ChangeApproval, Key: "e1", Name: "web", Field: "approvalType", Old: "na", New: "any"},
+		{Kind: ChangeSavings, Key: "e1", Name: "web", Field: "savings", Old: "30.00", New: "20.00", SavingsDelta: diffTestMoney("-10", "")},
+		{Kind: ChangeRemoved, Key: "e3", Name: "api", Old: "Terminate", SavingsDelta: diffTestMoney("-10", "")},
+		{Kind: ChangeContainerSizing, Key: "prod/shop/deployment/cart/app", Name: "prod/shop/deployment/cart/app", Field: "recommendedCpuRequest", Old: "250m", New: "500m"},
+		{Kind: ChangeContainerSizing, Key: "prod/shop/deployment/cart/app", Name: "prod/shop/deployment/cart/app", Field: "recommendedMemLimit", Old: "512Mi", New: "1Gi"},
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(diff.Changes), len(want), diff.Changes)
+	}
+	for i := 0; i < len(want); i++ {
+		if diff.Changes[i] != want[i] {
+			t.Errorf("Changes[%d] = %+v, want %+v", i, diff.Changes[i], want[i])
+		}
+	}
+	// +15 added, -10 changed, -10 removed
+	if diff.SavingsDelta.Amount() != "-5.00" || diff.SavingsDelta.IsMixedCurrency() {
+		t.Errorf("SavingsDelta = %s, want -5.00 USD/month", diff.SavingsDelta)
+	}
+	if diff.IsEmpty() || diff.Count(ChangeContainerSizing) != 2 || diff.Count(ChangeAdded) != 1 {
+		t.Errorf("IsEmpty() = %v, Count(containerSizing) = %d, Count(added) = %d", diff.IsEmpty(), diff.Count(ChangeContainerSizing), diff.Count(ChangeAdded))
+	}
+
+	same := DiffRecommendations(&oldRecos, &oldRecos)
+	if !same.IsEmpty() || !same.SavingsDelta.IsZero() {
+		t.Errorf("a diff of the same recommendations = %+v, want empty", same)
+	}
+	none := DiffRecommendations(nil, nil)
+	if !none.IsEmpty() || none.Changes == nil {
+		t.Errorf("a diff of nil recommendations = %+v, want an empty list of changes", none)
+	}
+}
+
+func TestDiffRecommendationsCurrencies(t *testing.T) {
+	tests := []struct {
+		name       string
+		oldSavings Money
+		newSavings Money
+		want       []RecommendationChange
+		mixed      bool
+	}{
+		{"same currency", diffTestMoney("10", "EUR"), diffTestMoney("10", "EUR"), nil, false},
+		{"default currency", diffTestMoney("10", ""), diffTestMoney("10", "USD"), nil, false},
+		{"zero in another currency", Money{}, diffTestMoney("0", "EUR"), nil, false},
+		{"same amount, another currency", diffTestMoney("10", "USD"), diffTestMoney("10", "EUR"), []RecommendationChange{
+			{Kind: ChangeSavings, Key: "e1", Name: "web", Field: "savings", Old: "10.00 USD/month", New: "10.00 EUR/month",
+				SavingsDelta: Money{Currency: MixedCurrency, Period: PeriodMonthly}},
+		}, true},
+		{"another amount, same currency", diffTestMoney("10", "EUR"), diffTestMoney("12", "EUR"), []RecommendationChange{
+			{Kind: ChangeSavings, Key: "e1", Name: "web", Field: "savings", Old: "10.00", New: "12.00", SavingsDelta: diffTestMoney("2", "EUR")},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldRecos := []DensifyRecommendation{{Name: "web", EntityId: "e1", SavingsEstimate: tt.oldSavings}}
+			newRecos := []DensifyRecommendation{{Name: "web", EntityId: "e1", SavingsEstimate: tt.newSavings}}
+			diff := DiffRecommendations(&oldRecos, &newRecos)
+			if len(diff.Changes) != len(tt.want) {
+				t.Fatalf("got %d changes, want %d: %+v", len(diff.Changes), len(tt.want), diff.Changes)
+			}
+			for i := 0; i < len(tt.want); i++ {
+				if diff.Changes[i] != tt.want[i] {
+					t.Errorf("Changes[%d] = %+v, want %+v", i, diff.Changes[i], tt.want[i])
+				}
+			}
+			if diff.SavingsDelta.IsMixedCurrency() != tt.mixed {
+				t.Errorf("SavingsDelta = %s, want mixed currency %v", diff.SavingsDelta, tt.mixed)
+			}
+		})
+	}
+}
+
+func TestRecommendationDiffText(t *testing.T) {
+	oldRecos := []DensifyRecommendation{
+		{Name: "web", EntityId: "e1", RecommendedType: "m5.large"},
+		{Name: "api", EntityId: "e3", RecommendationType: "Terminate", SavingsEstimate: diffTestMoney("10", "")},
+	}
+	newRecos := []DensifyRecommendation{
+		{Name: "cache", EntityId: "e0", RecommendationType: "Downsize", SavingsEstimate: diffTestMoney("15", "")},
+		{Name: "web", EntityId: "e1", RecommendedType: "m5.xlarge"},
+	}
+	diff := DiffRecommendations(&oldRecos, &newRecos)
+	want := "+ cache: Downsize, savings 15.00 USD/month\n" +
+		"~ web: recommendedType m5.large > m5.xlarge\n" +
+		"- api: Terminate, savings -10.00 USD/month\n" +
+		"3 change(s), savings change: 5.00 USD/month\n"
+	if got := diff.Text(); got != want {
+		t.Errorf("Text() =\n%s\nwant\n%s", got, want)
+	}
+
+	b, err := diff.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error: %v", err)
+	}
+	var got struct {
+		Changes []struct {
+			Kind         ChangeKind `json:"kind"`
+			Key          string     `json:"key"`
+			Field        string     `json:"field"`
+			SavingsDelta float64    `json:"savingsDelta"`
+		} `json:"changes"`
+		SavingsDelta float64 `json:"savingsDelta"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("JSON() isn't valid JSON: %v\n%s", err, b)
+	}
+	if len(got.Changes) != 3 || got.Changes[0].Kind != ChangeAdded || got.Changes[1].Field != "recommendedType" || got.Changes[2].SavingsDelta != -10 ||
+		got.SavingsDelta != 5 {
+		t.Errorf("JSON() =\n%s", b)
+	}
+
+	empty := DiffRecommendations(&oldRecos, &oldRecos)
+	if got := empty.Text(); got != "0 change(s), savings change: 0.00 USD/month\n" {
+		t.Errorf("Text() of an empty diff = %q", got)
+	}
+	if b, err := empty.JSON(); err != nil || string(b) != "{\n  \"changes\": [],\n  \"savingsDelta\": 0\n}" {
+		t.Errorf("JSON() of an empty diff = %s, %v", b, err)
+	}
+}
@@ -0,0 +1,269 @@
+package densify
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// The enum types below are strings so that values the Densify API adds in the future are preserved as they are (the "unknown" bucket);
+// IsKnown() reports whether a value is one of the constants. Parsing and JSON decoding are case and whitespace insensitive for the known values.
+
+// matches a value against the known values (case insensitive, ignoring repeated whitespace); unknown values are returned trimmed
+func canonicalEnumValue(s string, known []string) (string, bool) {
+	s = strings.Join(strings.Fields(s), " ")
+	for i := 0; i < len(known); i++ {
+		if strings.EqualFold(s, known[i]) {
+			return known[i], true
+		}
+	}
+	return s, false
+}
+
+// decodes a JSON string (or null) to be parsed into an enum value
+func unmarshalEnumString(data []byte) (string, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", err
+	}
+	if s == nil {
+		return "", nil
+	}
+	return *s, nil
+}
+
+// the approval status of a recommendation; any value other than the constants is the specific instance type that was approved
+type ApprovalType string
+
+const (
+	ApprovalNotApproved ApprovalType = "na"  // not approved; keep the current type
+	ApprovalAll         ApprovalType = "all" // any recommendation is approved
+	ApprovalAny         ApprovalType = "any" // any recommendation is approved
+)
+
+var knownApprovalTypes = []string{string(ApprovalNotApproved), string(ApprovalAll), string(ApprovalAny)}
+
+// Parse an approval type; "na", "all" and "any" are case insensitive, anything else is kept as the approved instance type.
+func ParseApprovalType(s string) ApprovalType {
+	v, _ := canonicalEnumValue(s, knownApprovalTypes)
+	return ApprovalType(v)
+}
+
+// returns true for "na", "all" and "any"; other values are a specific approved instance type
+func (a ApprovalType) IsKnown() bool {
+	_, ok := canonicalEnumValue(string(a), knownApprovalTypes)
+	return ok
+}
+
+// returns true if a change has been approved (all, any or a specific instance type)
+func (a ApprovalType) IsApproved() bool {
+	return a != "" && a != ApprovalNotApproved
+}
+
+// returns the specific instance type that was approved, if the approval is for a specific type
+func (a ApprovalType) ApprovedInstanceType() (string, bool) {
+	if a == "" || a.IsKnown() {
+		return "", false
+	}
+	return string(a), true
+}
+
+func (a ApprovalType) String() string {
+	return string(a)
+}
+
+func (a *ApprovalType) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalEnumString(data)
+	*a = ParseApprovalType(s)
+	return err
+}
+
+// the guardrails compatibility level of an instance type
+type Compatibility string
+
+const (
+	CompatibilityOK                      Compatibility = "OK"
+	CompatibilityTechnicallyIncompatible Compatibility = "Technically Incompatible"
+	CompatibilityInsufficientResources   Compatibility = "Insufficient Resources"
+	CompatibilityOutsideSpendTolerance   Compatibility = "Outside Spend Tolerance"
+)
+
+var knownCompatibilities = []string{
+	string(CompatibilityOK),
+	string(CompatibilityTechnicallyIncompatible),
+	string(CompatibilityInsufficientResources),
+	string(CompatibilityOutsideSpendTolerance),
+}
+
+// Parse a compatibility level; unknown values are preserved.
+func ParseCompatibility(s string) Compatibility {
+	v, _ := canonicalEnumValue(s, knownCompatibilities)
+	return Compatibility(v)
+}
+
+// returns true if the value is one of the Compatibility constants
+func (c Compatibility) IsKnown() bool {
+	_, ok := canonicalEnumValue(string(c), knownCompatibilities)
+	return ok
+}
+
+func (c Compatibility) String() string {
+	return string(c)
+}
+
+func (c *Compatibility) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalEnumString(data)
+	*c = ParseCompatibility(s)
+	return err
+}
+
+// the type of change Densify recommends
+type RecommendationType string
+
+const (
+	RecommendationJustRight              RecommendationType = "Just Right"
+	RecommendationTerminate              RecommendationType = "Terminate"
+	RecommendationDownsize               RecommendationType = "Downsize"
+	RecommendationUpsize                 RecommendationType = "Upsize"
+	RecommendationModernize              RecommendationType = "Modernize"
+	RecommendationResize                 RecommendationType = "Resize"
+	RecommendationResizeFromBurstable    RecommendationType = "Resize from Burstable"
+	RecommendationDownsizeOptimalFamily  RecommendationType = "Downsize - Optimal Family"
+	RecommendationUpsizeOptimalFamily    RecommendationType = "Upsize - Optimal Family"
+	RecommendationModernizeOptimalFamily RecommendationType = "Modernize - Optimal Family"
+	RecommendationResizeOptimalFamily    RecommendationType = "Resize - Optimal Family"
+	RecommendationNotAnalyzed            RecommendationType = "Not Analyzed"
+)
+
+var knownRecommendationTypes = []string{
+	string(RecommendationJustRight),
+	string(RecommendationTerminate),
+	string(RecommendationDownsize),
+	string(RecommendationUpsize),
+	string(RecommendationModernize),
+	string(RecommendationResize),
+	string(RecommendationResizeFromBurstable),
+	string(RecommendationDownsizeOptimalFamily),
+	string(RecommendationUpsizeOptimalFamily),
+	string(RecommendationModernizeOptimalFamily),
+	string(RecommendationResizeOptimalFamily),
+	string(RecommendationNotAnalyzed),
+}
+
+// Parse a recommendation type; unknown values are preserved.
+func ParseRecommendationType(s string) RecommendationType {
+	v, _ := canonicalEnumValue(s, knownRecommendationTypes)
+	return RecommendationType(v)
+}
+
+// returns true if the value is one of the RecommendationType constants
+func (t RecommendationType) IsKnown() bool {
+	_, ok := canonicalEnumValue(string(t), knownRecommendationTypes)
+	return ok
+}
+
+// returns true if the recommendation is to change the instance or container sizing (anything other than just right, or not analyzed)
+func (t RecommendationType) IsChange() bool {
+	return t != "" && t != RecommendationJustRight && t != RecommendationNotAnalyzed
+}
+
+func (t RecommendationType) String() string {
+	return string(t)
+}
+
+func (t *RecommendationType) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalEnumString(data)
+	*t = ParseRecommendationType(s)
+	return err
+}
+
+// the power state of a cloud instance
+type PowerState string
+
+const (
+	PowerStateRunning    PowerState = "Running"
+	PowerStateStopped    PowerState = "Stopped"
+	PowerStateTerminated PowerState = "Terminated"
+)
+
+var knownPowerStates = []string{string(PowerStateRunning), string(PowerStateStopped), string(PowerStateTerminated)}
+
+// Parse a power state; unknown values are preserved.
+func ParsePowerState(s string) PowerState {
+	v, _ := canonicalEnumValue(s, knownPowerStates)
+	return PowerState(v)
+}
+
+// returns true if the value is one of the PowerState constants
+func (p PowerState) IsKnown() bool {
+	_, ok := canonicalEnumValue(string(p), knownPowerStates)
+	return ok
+}
+
+func (p PowerState) String() string {
+	return string(p)
+}
+
+func (p *PowerState) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalEnumString(data)
+	*p = ParsePowerState(s)
+	return err
+}
+
+// the estimated effort to implement a recommendation
+type EffortEstimate string
+
+const (
+	EffortNone       EffortEstimate = "None"
+	EffortVeryLow    EffortEstimate = "Very Low"
+	EffortLow        EffortEstimate = "Low"
+	EffortMedium     EffortEstimate = "Medium"
+	EffortHigh       EffortEstimate = "High"
+	EffortVeryHigh   EffortEstimate = "Very High"
+	EffortImpossible EffortEstimate = "Impossible"
+)
+
+var knownEffortEstimates = []string{
+	string(EffortNone),
+	string(EffortVeryLow),
+	string(EffortLow),
+	string(EffortMedium),
+	string(EffortHigh),
+	string(EffortVeryHigh),
+	string(EffortImpossible),
+}
+
+// Parse an effort estimate; unknown values are preserved.
+func ParseEffortEstimate(s string) EffortEstimate {
+	v, _ := canonicalEnumValue(s, knownEffortEstimates)
+	return EffortEstimate(v)
+}
+
+// returns true if the value is one of the EffortEstimate constants
+func (e EffortEstimate) IsKnown() bool {
+	_, ok := canonicalEnumValue(string(e), knownEffortEstimates)
+	return ok
+}
+
+// returns the position of the effort from None (0) to Impossible (6), or -1 for unknown values; useful for sorting and thresholds
+func (e EffortEstimate) Level() int {
+	v, ok := canonicalEnumValue(string(e), knownEffortEstimates)
+	if !ok {
+		return -1
+	}
+	for i := 0; i < len(knownEffortEstimates); i++ {
+		if knownEffortEstimates[i] == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func (e EffortEstimate) String() string {
+	return string(e)
+}
+
+func (e *EffortEstimate) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalEnumString(data)
+	*e = ParseEffortEstimate(s)
+	return err
+}
@@ -0,0 +1,466 @@
+package densify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the exporters build the recommendations as ordered data first and then write it in each format, so every format has the same keys,
+// fields and ordering. values are: string, int64, float64, bool, Money (a number), []interface{} (a list) and *exportObject.
+
+// an attribute of an exported object
+type exportAttribute struct {
+	key   string
+	value interface{}
+}
+
+// an exported object; attributes are written in the order they were added
+type exportObject struct {
+	attributes []exportAttribute
+	quoteKeys  bool // the keys are data (ex. names) rather than field names; HCL writes them as quoted strings
+}
+
+func (o *exportObject) set(key string, value interface{}) {
+	o.attributes = append(o.attributes, exportAttribute{key: key, value: value})
+}
+
+// the format to export recommendations in
+type ExportFormat string
+
+const (
+	ExportHCL        ExportFormat = "tfvars"      // a terraform .tfvars file (HCL)
+	ExportTFVarsJSON ExportFormat = "tfvars.json" // a terraform .tfvars.json file
+	ExportJSON       ExportFormat = "json"
+	ExportYAML       ExportFormat = "yaml"
+	ExportCSV        ExportFormat = "csv"
+	ExportTSV        ExportFormat = "tsv"
+)
+
+// how exported recommendations are keyed
+type ExportKeyStrategy string
+
+const (
+	KeyByDefault     ExportKeyStrategy = ""            // the system name for cloud, and cluster/namespace/controllerType/podService/container for kubernetes
+	KeyBySystemName  ExportKeyStrategy = "systemName"  // the system name
+	KeyByWorkloadKey ExportKeyStrategy = "workloadKey" // cluster/namespace/controllerType/podService/container (kubernetes only)
+	KeyByEntityId    ExportKeyStrategy = "entityId"    // the Densify entity id
+)
+
+// the default terraform variable name for the tfvars exporters
+const DefaultTFVarName = "densify_recommendations"
+
+// options shared by the exporters
+type ExportOptions struct {
+	KeyBy   ExportKeyStrategy
+	Fields  []string // the fields to export, in order (see ExportFieldNames); empty exports every field that applies to each recommendation
+	VarName string   // the terraform variable name, for the tfvars exporters; defaults to densify_recommendations
+}
+
+// Export recommendations to a writer.
+type RecommendationExporter interface {
+	Export(w io.Writer, recos *[]DensifyRecommendation) error
+}
+
+// Returns the exporter for a format.
+func NewRecommendationExporter(format ExportFormat, opts ExportOptions) (RecommendationExporter, error) {
+	switch format {
+	case ExportHCL:
+		return &HCLExporter{Options: opts}, nil
+	case ExportTFVarsJSON:
+		return &TFVarsJSONExporter{Options: opts}, nil
+	case ExportJSON:
+		return &JSONExporter{Options: opts}, nil
+	case ExportYAML:
+		return &YAMLExporter{Options: opts}, nil
+	case ExportCSV:
+		return &CSVExporter{Options: opts, Delimiter: ','}, nil
+	case ExportTSV:
+		return &CSVExporter{Options: opts, Delimiter: '\t'}, nil
+	default:
+		return nil, fmt.Errorf("invalid export format '%s'; must be one of: tfvars, tfvars.json, json, yaml, csv, tsv", format)
+	}
+}
+
+// which recommendations a field applies to, when no fields are selected
+type exportScope int
+
+const (
+	exportAll exportScope = iota
+	exportCloud
+	exportASG // cloud auto scaling groups
+	exportKubernetes
+)
+
+// an exportable recommendation field; CPU is in millicores, memory is in MiB and costs are numbers
+type exportField struct {
+	name  string
+	scope exportScope
+	value func(r *DensifyRecommendation) interface{} // nil values are left out
+}
+
+var exportFields = []exportField{
+	{"analysisType", exportAll, func(r *DensifyRecommendation) interface{} { return r.AnalysisType }},
+	{"analysisTechnology", exportAll, func(r *DensifyRecommendation) interface{} { return r.AnalysisTechnology }},
+	{"accountIdRef", exportAll, func(r *DensifyRecommendation) interface{} { return r.AccountIdRef }},
+	{"recommendationType", exportAll, func(r *DensifyRecommendation) interface{} { return string(r.RecommendationType) }},
+	{"approvalType", exportAll, func(r *DensifyRecommendation) interface{} { return string(r.ApprovalType) }},
+	{"densifyPolicy", exportAll, func(r *DensifyRecommendation) interface{} { return r.DensifyPolicy }},
+
+	{"entityId", exportCloud, func(r *DensifyRecommendation) interface{} { return r.EntityId }},
+	{"name", exportCloud, func(r *DensifyRecommendation) interface{} { return r.Name }},
+	{"region", exportCloud, func(r *DensifyRecommendation) interface{} { return r.Region }},
+	{"serviceType", exportCloud, func(r *DensifyRecommendation) interface{} { return r.ServiceType }},
+	{"currentType", exportCloud, func(r *DensifyRecommendation) interface{} { return r.CurrentType }},
+	{"recommendedType", exportCloud, func(r *DensifyRecommendation) interface{} { return r.RecommendedType }},
+	{"approvedType", exportCloud, func(r *DensifyRecommendation) interface{} { return r.GetApprovedType() }},
+	{"powerState", exportCloud, func(r *DensifyRecommendation) interface{} { return string(r.PowerState) }},
+	{"predictedUptime", exportCloud, func(r *DensifyRecommendation) interface{} { return float64(r.PredictedUptime) }},
+	{"implementationMethod", exportCloud, func(r *DensifyRecommendation) interface{} { return r.ImplementationMethod }},
+	{"savingsEstimate", exportCloud, func(r *DensifyRecommendation) interface{} { return r.SavingsEstimate }},
+	{"effortEstimate", exportCloud, func(r *DensifyRecommendation) interface{} { return string(r.EffortEstimate) }},
+
+	{"minGroupCurrent", exportASG, func(r *DensifyRecommendation) interface{} { return r.MinGroupCurrent }},
+	{"minGroupRecommended", exportASG, func(r *DensifyRecommendation) interface{} { return r.MinGroupRecommended }},
+	{"maxGroupCurrent", exportASG, func(r *DensifyRecommendation) interface{} { return r.MaxGroupCurrent }},
+	{"maxGroupRecommended", exportASG, func(r *DensifyRecommendation) interface{} { return r.MaxGroupRecommended }},
+	{"currentDesiredCapacity", exportASG, func(r *DensifyRecommendation) interface{} { return r.CurrentDesiredCapacity }},
+	{"avgInstanceCountCurrent", exportASG, func(r *DensifyRecommendation) interface{} { return float64(r.AvgInstanceCountCurrent) }},
+	{"avgInstanceCountRecommended", exportASG, func(r *DensifyRecommendation) interface{} { return float64(r.AvgInstanceCountRecommended) }},
+
+	{"cluster", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.Cluster }},
+	{"namespace", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.Namespace }},
+	{"controllerType", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.ControllerType }},
+	{"podService", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.PodService }},
+	{"container", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.Container }},
+	{"displayName", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.DisplayName }},
+	{"estimatedSavings", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.EstimatedSavings }},
+	{"totalNetSavings", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.TotalNetSavings }},
+	{"currentCount", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.CurrentCount }},
+	{"currentCpuRequest", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.CurrentCpuRequest.Millicores() }},
+	{"currentCpuLimit", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.CurrentCpuLimit.Millicores() }},
+	{"currentMemRequest", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.CurrentMemRequest.MiB() }},
+	{"currentMemLimit", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.CurrentMemLimit.MiB() }},
+	{"recommendedCpuRequest", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.RecommendedCpuRequest.Millicores() }},
+	{"recommendedCpuLimit", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.RecommendedCpuLimit.Millicores() }},
+	{"recommendedMemRequest", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.RecommendedMemRequest.MiB() }},
+	{"recommendedMemLimit", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.RecommendedMemLimit.MiB() }},
+	{"runningHours", exportKubernetes, func(r *DensifyRecommendation) interface{} { return r.RunningHours }},
+	{"containers", exportKubernetes, func(r *DensifyRecommendation) interface{} {
+		if len(r.Containers) == 0 {
+			return nil
+		}
+		containers := make([]interface{}, 0, len(r.Containers))
+		for i := 0; i < len(r.Containers); i++ {
+			containers = append(containers, r.Containers[i].exportObject())
+		}
+		return containers
+	}},
+}
+
+// returns true if the field is exported for the recommendation by default
+func (f *exportField) appliesTo(r *DensifyRecommendation) bool {
+	switch f.scope {
+	case exportCloud:
+		return !r.isKubernetes()
+	case exportASG:
+		return !r.isKubernetes() && r.isASG()
+	case exportKubernetes:
+		return r.isKubernetes()
+	default:
+		return true
+	}
+}
+
+// returns true if the recommendation is for an auto scaling group
+func (r *DensifyRecommendation) isASG() bool {
+	return r.MinGroupCurrent != "" || r.MaxGroupCurrent != "" || r.CurrentDesiredCapacity != "" || r.AvgInstanceCountCurrent != 0
+}
+
+// Returns the names of the fields that can be exported, in their default order.
+func ExportFieldNames() []string {
+	names := make([]string, len(exportFields))
+	for i := 0; i < len(exportFields); i++ {
+		names[i] = exportFields[i].name
+	}
+	return names
+}
+
+// returns the object for a container of a pod-level recommendation
+func (c *DensifyContainerRecommendation) exportObject() *exportObject {
+	o := &exportObject{}
+	o.set("container", c.Container)
+	o.set("recommendationType", string(c.RecommendationType))
+	o.set("approvalType", string(c.ApprovalType))
+	o.set("estimatedSavings", c.EstimatedSavings)
+	o.set("totalNetSavings", c.TotalNetSavings)
+	o.set("currentCount", c.CurrentCount)
+	o.set("currentCpuRequest", c.CurrentCpuRequest.Millicores())
+	o.set("currentCpuLimit", c.CurrentCpuLimit.Millicores())
+	o.set("currentMemRequest", c.CurrentMemRequest.MiB())
+	o.set("currentMemLimit", c.CurrentMemLimit.MiB())
+	o.set("recommendedCpuRequest", c.RecommendedCpuRequest.Millicores())
+	o.set("recommendedCpuLimit", c.RecommendedCpuLimit.Millicores())
+	o.set("recommendedMemRequest", c.RecommendedMemRequest.MiB())
+	o.set("recommendedMemLimit", c.RecommendedMemLimit.MiB())
+	o.set("runningHours", c.RunningHours)
+	return o
+}
+
+// returns the selected fields, or nil to export every field that applies to each recommendation
+func (opts *ExportOptions) fields() ([]exportField, error) {
+	if len(opts.Fields) == 0 {
+		return nil, nil
+	}
+	byName := map[string]exportField{}
+	for i := 0; i < len(exportFields); i++ {
+		byName[exportFields[i].name] = exportFields[i]
+	}
+	selected := []exportField{}
+	seen := map[string]bool{}
+	for i := 0; i < len(opts.Fields); i++ {
+		field, found := byName[opts.Fields[i]]
+		if !found {
+			return nil, fmt.Errorf("invalid export field '%s'; must be one of: %s", opts.Fields[i], strings.Join(ExportFieldNames(), ", "))
+		}
+		if seen[field.name] {
+			return nil, fmt.Errorf("export field '%s' is selected more than once", field.name)
+		}
+		seen[field.name] = true
+		selected = append(selected, field)
+	}
+	return selected, nil
+}
+
+// returns the terraform variable name, or an error if it isn't a valid identifier
+func (opts *ExportOptions) varName() (string, error) {
+	if opts.VarName == "" {
+		return DefaultTFVarName, nil
+	}
+	if !hclIdentifierRegexp.MatchString(opts.VarName) {
+		return "", fmt.Errorf("invalid terraform variable name '%s'; must start with a letter or underscore and only contain letters, digits, underscores and dashes", opts.VarName)
+	}
+	return opts.VarName, nil
+}
+
+// returns the key of a recommendation for the strategy
+func (s ExportKeyStrategy) key(r *DensifyRecommendation) (string, error) {
+	switch s {
+	case KeyByDefault:
+		if r.isKubernetes() {
+			if r.Container == "" {
+				return r.WorkloadKey().String(), nil
+			}
+			return r.ContainerKey().String(), nil
+		}
+		if r.Name == "" {
+			return r.EntityId, nil
+		}
+		return r.Name, nil
+	case KeyBySystemName:
+		if r.Name == "" {
+			return "", fmt.Errorf("recommendation for entity '%s' has no system name to export it by", r.EntityId)
+		}
+		return r.Name, nil
+	case KeyByWorkloadKey:
+		if !r.isKubernetes() {
+			return "", fmt.Errorf("recommendation '%s' isn't for a kubernetes workload, so it can't be exported by workload key", r.Name)
+		}
+		return KeyByDefault.key(r)
+	case KeyByEntityId:
+		if r.EntityId == "" {
+			return "", fmt.Errorf("recommendation '%s' has no entity id to export it by", r.summaryName())
+		}
+		return r.EntityId, nil
+	default:
+		return "", fmt.Errorf("invalid export key strategy '%s'; must be one of: systemName, workloadKey, entityId (or empty for the default)", s)
+	}
+}
+
+// returns the object for a recommendation, with the selected fields (or every field that applies to it)
+func (r *DensifyRecommendation) exportObject(fields []exportField) *exportObject {
+	o := &exportObject{}
+	all := fields == nil
+	if all {
+		fields = exportFields
+	}
+	for i := 0; i < len(fields); i++ {
+		if all && !fields[i].appliesTo(r) {
+			continue
+		}
+		if value := fields[i].value(r); value != nil {
+			o.set(fields[i].name, value)
+		}
+	}
+	return o
+}
+
+// returns the recommendations as an object keyed by the options' key strategy and sorted by key; if two recommendations have the same
+// key, the entity id (or a number) is added to the later one(s) so the keys stay unique
+func buildRecommendationExport(recos *[]DensifyRecommendation, opts *ExportOptions) (*exportObject, error) {
+	fields, err := opts.fields()
+	if err != nil {
+		return nil, err
+	}
+	type entry struct {
+		key  string
+		reco *DensifyRecommendation
+	}
+	entries := []entry{}
+	if recos != nil {
+		for i := 0; i < len(*recos); i++ {
+			reco := &(*recos)[i]
+			key, err := opts.KeyBy.key(reco)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{key: key, reco: reco})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	used := map[string]bool{}
+	for i := 0; i < len(entries); i++ {
+		key := entries[i].key
+		if used[key] && entries[i].reco.EntityId != "" && opts.KeyBy != KeyByEntityId {
+			key = fmt.Sprintf("%s (%s)", entries[i].key, entries[i].reco.EntityId)
+		}
+		for n := 2; used[key]; n++ {
+			key = fmt.Sprintf("%s (%d)", entries[i].key, n)
+		}
+		used[key] = true
+		entries[i].key = key
+	}
+
+	m := &exportObject{quoteKeys: true}
+	for i := 0; i < len(entries); i++ {
+		m.set(entries[i].key, entries[i].reco.exportObject(fields))
+	}
+	return m, nil
+}
+
+// returns a string as JSON, without escaping HTML characters
+func jsonQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// strings always encode
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// writes a value as indented JSON, keeping the order of object attributes
+func writeJSONValue(sb *strings.Builder, value interface{}, indent int) error {
+	switch v := value.(type) {
+	case string:
+		sb.WriteString(jsonQuote(v))
+	case int64:
+		sb.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			sb.WriteString("null")
+		} else {
+			sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	case bool:
+		sb.WriteString(strconv.FormatBool(v))
+	case Money:
+		sb.WriteString(v.Amount())
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString("[]")
+			return nil
+		}
+		sb.WriteString("[\n")
+		for i := 0; i < len(v); i++ {
+			sb.WriteString(strings.Repeat("  ", indent+1))
+			if err := writeJSONValue(sb, v[i], indent+1); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				sb.WriteString(",")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(strings.Repeat("  ", indent) + "]")
+	case *exportObject:
+		if len(v.attributes) == 0 {
+			sb.WriteString("{}")
+			return nil
+		}
+		sb.WriteString("{\n")
+		for i := 0; i < len(v.attributes); i++ {
+			sb.WriteString(strings.Repeat("  ", indent+1) + jsonQuote(v.attributes[i].key) + ": ")
+			if err := writeJSONValue(sb, v.attributes[i].value, indent+1); err != nil {
+				return err
+			}
+			if i < len(v.attributes)-1 {
+				sb.WriteString(",")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(strings.Repeat("  ", indent) + "}")
+	default:
+		return fmt.Errorf("unsupported JSON value type %T", value)
+	}
+	return nil
+}
+
+// returns a value as indented JSON, followed by a line break
+func formatJSON(value interface{}) (string, error) {
+	var sb strings.Builder
+	if err := writeJSONValue(&sb, value, 0); err != nil {
+		return "", err
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
+
+// Exports the recommendations as a JSON object keyed by the options' key strategy.
+type JSONExporter struct {
+	Options ExportOptions
+}
+
+func (e *JSONExporter) Export(w io.Writer, recos *[]DensifyRecommendation) error {
+	m, err := buildRecommendationExport(recos, &e.Options)
+	if err != nil {
+		return err
+	}
+	doc, err := formatJSON(m)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, doc)
+	return err
+}
+
+// Exports the recommendations as a terraform .tfvars.json file, with the recommendations map as the options' variable.
+type TFVarsJSONExporter struct {
+	Options ExportOptions
+}
+
+func (e *TFVarsJSONExporter) Export(w io.Writer, recos *[]DensifyRecommendation) error {
+	varName, err := e.Options.varName()
+	if err != nil {
+		return err
+	}
+	m, err := buildRecommendationExport(recos, &e.Options)
+	if err != nil {
+		return err
+	}
+	root := &exportObject{}
+	root.set(varName, m)
+	doc, err := formatJSON(root)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, doc)
+	return err
+}
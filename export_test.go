@@ -0,0 +1,175 @@
+package densify
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// returns the keys of the JSON object in the order they were written
+func jsonObjectKeys(t *testing.T, data []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		t.Fatalf("Token() = %v, %v; want {", tok, err)
+	}
+	keys := []string{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf("Token() error: %v", err)
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			t.Fatalf("Decode() error: %v", err)
+		}
+	}
+	return keys
+}
+
+func TestJSONExporter(t *testing.T) {
+	recos := hclTestRecommendations()
+	var buf bytes.Buffer
+	if err := (&JSONExporter{}).Export(&buf, &recos); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	wantKeys := []string{"api", "prod/shop/deployment/cart", "prod/shop/deployment/cart/app", recos[0].Name}
+	if got := jsonObjectKeys(t, buf.Bytes()); strings.Join(got, "|") != strings.Join(wantKeys, "|") {
+		t.Errorf("keys = %q, want %q", got, wantKeys)
+	}
+
+	var out map[string]map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Unmarshal() error: %v\n%s", err, buf.String())
+	}
+	tests := []struct {
+		key   string
+		field string
+		want  interface{}
+	}{
+		{recos[0].Name, "name", recos[0].Name},
+		{recos[0].Name, "savingsEstimate", 12.5},
+		{recos[0].Name, "predictedUptime", 99.5},
+		{"api", "minGroupCurrent", "1"},
+		{"prod/shop/deployment/cart/app", "recommendedCpuRequest", float64(250)},
+		{"prod/shop/deployment/cart/app", "recommendedMemLimit", float64(512)},
+	}
+	for _, tt := range tests {
+		if got := out[tt.key][tt.field]; got != tt.want {
+			t.Errorf("%s.%s = %#v, want %#v", tt.key, tt.field, got, tt.want)
+		}
+	}
+	// strings are written without HTML escaping
+	if got := jsonQuote("<a & b>"); got != `"<a & b>"` {
+		t.Errorf("jsonQuote() = %s, want \"<a & b>\"", got)
+	}
+}
+
+func TestUnsupportedExportValues(t *testing.T) {
+	o := &exportObject{}
+	o.set("values", []interface{}{int64(1), int32(2)})
+	if got, err := formatJSON(o); err == nil {
+		t.Errorf("formatJSON() = %s, want an error for an unsupported type", got)
+	}
+	if got, err := compactJSON(o); err == nil {
+		t.Errorf("compactJSON() = %s, want an error for an unsupported type", got)
+	}
+	if got, err := formatHCLAttribute("recos", o); err == nil {
+		t.Errorf("formatHCLAttribute() = %s, want an error for an unsupported type", got)
+	}
+}
+
+func TestTFVarsJSONExporter(t *testing.T) {
+	recos := hclTestRecommendations()
+	var buf bytes.Buffer
+	if err := (&TFVarsJSONExporter{Options: ExportOptions{VarName: "recos", Fields: []string{"name", "currentType"}}}).Export(&buf, &recos); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	var out map[string]map[string]map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Unmarshal() error: %v\n%s", err, buf.String())
+	}
+	if len(out) != 1 || out["recos"]["api"]["currentType"] != "t3.large" {
+		t.Errorf("output = %v, want a recos variable", out)
+	}
+	// only the selected fields are written, in the selected order
+	if got := out["recos"]["api"]; len(got) != 2 {
+		t.Errorf("api = %v, want only name and currentType", got)
+	}
+	if !strings.Contains(buf.String(), "\"name\": \"api\",\n      \"currentType\": \"t3.large\"") {
+		t.Errorf("fields aren't in the selected order:\n%s", buf.String())
+	}
+
+	if err := (&TFVarsJSONExporter{Options: ExportOptions{VarName: "1recos"}}).Export(&buf, &recos); err == nil {
+		t.Errorf("Export() with an invalid variable name didn't return an error")
+	}
+}
+
+func TestExportOptions(t *testing.T) {
+	recos := []DensifyRecommendation{
+		{EntityId: "e2", Name: "web"},
+		{EntityId: "e1", Name: "web"},
+		{Name: "web"},
+		{EntityId: "e3", Namespace: "shop", PodService: "cart"},
+	}
+	tests := []struct {
+		name   string
+		opts   ExportOptions
+		recos  []DensifyRecommendation
+		keys   []string
+		hasErr bool
+	}{
+		{"default keys, duplicates get the entity id", ExportOptions{}, recos, []string{"/shop//cart", "web", "web (e1)", "web (2)"}, false},
+		{"entity id", ExportOptions{KeyBy: KeyByEntityId}, recos[:2], []string{"e1", "e2"}, false},
+		{"entity id missing", ExportOptions{KeyBy: KeyByEntityId}, recos, nil, true},
+		{"system name missing", ExportOptions{KeyBy: KeyBySystemName}, recos, nil, true},
+		{"workload key", ExportOptions{KeyBy: KeyByWorkloadKey}, recos[3:], []string{"/shop//cart"}, false},
+		{"workload key for cloud", ExportOptions{KeyBy: KeyByWorkloadKey}, recos, nil, true},
+		{"invalid key strategy", ExportOptions{KeyBy: "owner"}, recos, nil, true},
+		{"invalid field", ExportOptions{Fields: []string{"name", "owner"}}, recos, nil, true},
+		{"duplicate field", ExportOptions{Fields: []string{"name", "name"}}, recos, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := buildRecommendationExport(&tt.recos, &tt.opts)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("buildRecommendationExport() didn't return an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildRecommendationExport() error: %v", err)
+			}
+			keys := []string{}
+			for i := 0; i < len(m.attributes); i++ {
+				keys = append(keys, m.attributes[i].key)
+			}
+			if strings.Join(keys, "|") != strings.Join(tt.keys, "|") {
+				t.Errorf("keys = %q, want %q", keys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestNewRecommendationExporter(t *testing.T) {
+	recos := hclTestRecommendations()
+	formats := []ExportFormat{ExportHCL, ExportTFVarsJSON, ExportJSON, ExportYAML, ExportCSV, ExportTSV}
+	for _, format := range formats {
+		t.Run(string(format), func(t *testing.T) {
+			e, err := NewRecommendationExporter(format, ExportOptions{})
+			if err != nil {
+				t.Fatalf("NewRecommendationExporter() error: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := e.Export(&buf, &recos); err != nil || buf.Len() == 0 {
+				t.Errorf("Export() = %d bytes, %v", buf.Len(), err)
+			}
+		})
+	}
+	if _, err := NewRecommendationExporter("xml", ExportOptions{}); err == nil {
+		t.Errorf("NewRecommendationExporter(xml) didn't return an error")
+	}
+}
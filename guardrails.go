@@ -7,7 +7,7 @@ import (
 )
 
 type DensifyGuardrailsList struct {
-	Compatibility string                                   `json:"compatibility"`
+	Compatibility Compatibility                            `json:"compatibility"`
 	InstanceList  map[int]map[string]DensifyGuardrailsNode `json:"nodeList"`
 }
 type DensifyGuardrailsNode struct {
@@ -95,35 +95,39 @@ func (l *DensifyGuardrailsList) ToArrayOfInstances() []string {
 }
 
 func (r *DensifyRecommendation) GetGuardrailsOK() (*DensifyGuardrailsList, error) {
-	return r.GetGuardrailsCompatLevel("OK")
+	return r.GetGuardrailsCompatibility(CompatibilityOK)
 }
 func (r *DensifyRecommendation) GetGuardrailsIncompatible() (*DensifyGuardrailsList, error) {
-	return r.GetGuardrailsCompatLevel("Technically Incompatible")
+	return r.GetGuardrailsCompatibility(CompatibilityTechnicallyIncompatible)
 }
 func (r *DensifyRecommendation) GetGuardrailsInsufficientResources() (*DensifyGuardrailsList, error) {
-	return r.GetGuardrailsCompatLevel("Insufficient Resources")
+	return r.GetGuardrailsCompatibility(CompatibilityInsufficientResources)
 }
 func (r *DensifyRecommendation) GetGuardrailsOutsideSpendTolerance() (*DensifyGuardrailsList, error) {
-	return r.GetGuardrailsCompatLevel("Outside Spend Tolerance")
+	return r.GetGuardrailsCompatibility(CompatibilityOutsideSpendTolerance)
 }
 
 func (r *DensifyRecommendation) GetGuardrailsCompatLevel(compatibilityLevel string) (*DensifyGuardrailsList, error) {
+	return r.GetGuardrailsCompatibility(ParseCompatibility(compatibilityLevel))
+}
+
+// returns the guardrails targets with a compatibility level; the typed form of GetGuardrailsCompatLevel
+func (r *DensifyRecommendation) GetGuardrailsCompatibility(compatibilityLevel Compatibility) (*DensifyGuardrailsList, error) {
 	targets := r.Guardrails.getCompatibilityList(compatibilityLevel)
 	if targets == nil {
 		return nil, fmt.Errorf("no instance governance list available for instance: %s", r.Name)
 	}
 	return targets, nil
 }
-func (g *DensifyGuardrails) getCompatibilityList(compat string) *DensifyGuardrailsList {
+func (g *DensifyGuardrails) getCompatibilityList(compat Compatibility) *DensifyGuardrailsList {
 	l := DensifyGuardrailsList{
 		Compatibility: compat,
 		InstanceList:  map[int]map[string]DensifyGuardrailsNode{},
 	}
 
-	compatLowerCase := strings.ToLower(compat)
 	for i := 0; i < len(g.Targets); i++ {
 		item := g.Targets[i]
-		if strings.ToLower(item.Compatibility) == compatLowerCase {
+		if strings.EqualFold(string(item.Compatibility), string(compat)) {
 			l.AddNode(item.InstanceType, item.BlendedScore, float64(item.PercentOptimalCost))
 		}
 	}
@@ -0,0 +1,41 @@
+package densify
+
+import "testing"
+
+func TestGetGuardrailsCompatLevel(t *testing.T) {
+	r := DensifyRecommendation{Guardrails: DensifyGuardrails{Targets: []DensifyGuardrailsTarget{
+		{InstanceType: "m5.xlarge", BlendedScore: 90, Compatibility: CompatibilityOK},
+		{InstanceType: "m5.2xlarge", BlendedScore: 70, Compatibility: "ok"},
+		{InstanceType: "t3.large", BlendedScore: 50, Compatibility: CompatibilityTechnicallyIncompatible},
+	}}}
+	tests := []struct {
+		level string
+		want  int
+	}{
+		{"OK", 2},
+		{"ok", 2},
+		{"technically incompatible", 1},
+		{"Insufficient Resources", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			// a string variable, not an untyped constant, so the string signature is checked
+			level := tt.level
+			list, err := r.GetGuardrailsCompatLevel(level)
+			if err != nil {
+				t.Fatalf("GetGuardrailsCompatLevel(%s) error: %v", level, err)
+			}
+			if list.TotalLength() != tt.want {
+				t.Errorf("GetGuardrailsCompatLevel(%s) has %d targets, want %d", level, list.TotalLength(), tt.want)
+			}
+			typed, _ := r.GetGuardrailsCompatibility(ParseCompatibility(level))
+			if typed.TotalLength() != tt.want {
+				t.Errorf("GetGuardrailsCompatibility(%s) has %d targets, want %d", level, typed.TotalLength(), tt.want)
+			}
+		})
+	}
+	ok, _ := r.GetGuardrailsOK()
+	if ok.GetMaxScore() != 90 || ok.GetMinScore() != 70 {
+		t.Errorf("GetGuardrailsOK() scores = %d-%d, want 70-90", ok.GetMinScore(), ok.GetMaxScore())
+	}
+}
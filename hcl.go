@@ -0,0 +1,142 @@
+package densify
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hclIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// returns the key as an identifier, if it's a valid one (and the object doesn't quote its keys), otherwise as a quoted string
+func (o *exportObject) hclKey(key string) string {
+	if !o.quoteKeys && hclIdentifierRegexp.MatchString(key) {
+		return key
+	}
+	return hclQuote(key)
+}
+
+// returns the string as a quoted HCL string; quotes, backslashes and control characters are escaped, as well as template sequences
+// ("${" and "%{"), so names are never interpolated by terraform
+func hclQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"':
+			sb.WriteString(`\"`)
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			sb.WriteString(fmt.Sprintf(`\u%04X`, r))
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			// "$${" and "%%{" are the literal "${" and "%{"
+			sb.WriteRune(r)
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// writes a value; nested objects and tuples are indented by two spaces per level
+func writeHCLValue(sb *strings.Builder, value interface{}, indent int) error {
+	switch v := value.(type) {
+	case string:
+		sb.WriteString(hclQuote(v))
+	case int64:
+		sb.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			sb.WriteString("null")
+		} else {
+			sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	case bool:
+		sb.WriteString(strconv.FormatBool(v))
+	case Money:
+		sb.WriteString(v.Amount())
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString("[]")
+			return nil
+		}
+		sb.WriteString("[\n")
+		for i := 0; i < len(v); i++ {
+			sb.WriteString(strings.Repeat("  ", indent+1))
+			if err := writeHCLValue(sb, v[i], indent+1); err != nil {
+				return err
+			}
+			sb.WriteString(",\n")
+		}
+		sb.WriteString(strings.Repeat("  ", indent) + "]")
+	case *exportObject:
+		if len(v.attributes) == 0 {
+			sb.WriteString("{}")
+			return nil
+		}
+		// align the equals signs, the same as terraform fmt
+		width := 0
+		for i := 0; i < len(v.attributes); i++ {
+			width = max(width, len(v.hclKey(v.attributes[i].key)))
+		}
+		sb.WriteString("{\n")
+		for i := 0; i < len(v.attributes); i++ {
+			key := v.hclKey(v.attributes[i].key)
+			sb.WriteString(strings.Repeat("  ", indent+1) + key + strings.Repeat(" ", width-len(key)) + " = ")
+			if err := writeHCLValue(sb, v.attributes[i].value, indent+1); err != nil {
+				return err
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(strings.Repeat("  ", indent) + "}")
+	default:
+		return fmt.Errorf("unsupported HCL value type %T", value)
+	}
+	return nil
+}
+
+// Exports the recommendations as a terraform .tfvars file (HCL), with the recommendations map as the options' variable. The output can
+// also be used as an attribute of a locals block.
+type HCLExporter struct {
+	Options ExportOptions
+}
+
+func (e *HCLExporter) Export(w io.Writer, recos *[]DensifyRecommendation) error {
+	varName, err := e.Options.varName()
+	if err != nil {
+		return err
+	}
+	m, err := buildRecommendationExport(recos, &e.Options)
+	if err != nil {
+		return err
+	}
+	attr, err := formatHCLAttribute(varName, m)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, attr)
+	return err
+}
+
+// returns an attribute as HCL
+func formatHCLAttribute(name string, value interface{}) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(name + " = ")
+	if err := writeHCLValue(&sb, value, 0); err != nil {
+		return "", err
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
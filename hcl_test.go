@@ -0,0 +1,357 @@
+package densify
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// recommendations with names that need escaping in HCL
+func hclTestRecommendations() []DensifyRecommendation {
+	savings, _ := ParseMoney("12.5", "", PeriodMonthly)
+	return []DensifyRecommendation{
+		{EntityId: "e2", Name: `web "prod" ${var.x} %{if true}y%{endif}` + "\nline2\\", CurrentType: "m5.xlarge", RecommendedType: "m5.large", ApprovalType: ApprovalAll, PredictedUptime: 99.5, SavingsEstimate: savings},
+		{EntityId: "e1", Name: "api", CurrentType: "t3.large", MinGroupCurrent: "1", AvgInstanceCountCurrent: 2.5},
+		{Cluster: "prod", Namespace: "shop", ControllerType: "deployment", PodService: "cart", Container: "app", CurrentCount: 3, RecommendedCpuRequest: 250, RecommendedMemLimit: 512, EstimatedSavings: savings},
+		{Cluster: "prod", Namespace: "shop", ControllerType: "deployment", PodService: "cart", Containers: []DensifyContainerRecommendation{{Container: "app", RunningHours: 720}}},
+	}
+}
+
+// an object parsed by hclTestParser, with its keys in order
+type hclTestObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// a number parsed by hclTestParser, as written
+type hclTestNumber string
+
+// parses the subset of HCL the exporter writes: a single attribute whose value is made of objects, tuples, quoted strings, numbers, bools
+// and null. Template sequences ("${" and "%{") that weren't escaped are errors, as terraform would interpolate them.
+type hclTestParser struct {
+	src string
+	pos int
+}
+
+func (p *hclTestParser) skipSpace() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *hclTestParser) expect(s string) error {
+	p.skipSpace()
+	if !strings.HasPrefix(p.src[p.pos:], s) {
+		return fmt.Errorf("expected %q at offset %d", s, p.pos)
+	}
+	p.pos += len(s)
+	return nil
+}
+
+func (p *hclTestParser) identifier() (string, error) {
+	p.skipSpace()
+	match := regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*`).FindString(p.src[p.pos:])
+	if match == "" {
+		return "", fmt.Errorf("expected an identifier at offset %d", p.pos)
+	}
+	p.pos += len(match)
+	return match, nil
+}
+
+func (p *hclTestParser) quoted() (string, error) {
+	if err := p.expect(`"`); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		rest := p.src[p.pos:]
+		switch {
+		case rest[0] == '"':
+			p.pos++
+			return sb.String(), nil
+		case rest[0] == '\n':
+			return "", fmt.Errorf("newline in a quoted string at offset %d", p.pos)
+		case strings.HasPrefix(rest, "$${") || strings.HasPrefix(rest, "%%{"):
+			sb.WriteString(rest[1:3])
+			p.pos += 3
+		case strings.HasPrefix(rest, "${") || strings.HasPrefix(rest, "%{"):
+			return "", fmt.Errorf("template sequence at offset %d", p.pos)
+		case rest[0] == '\\' && len(rest) > 1:
+			escapes := map[byte]string{'"': `"`, '\\': `\`, 'n': "\n", 'r': "\r", 't': "\t"}
+			if e, ok := escapes[rest[1]]; ok {
+				sb.WriteString(e)
+				p.pos += 2
+				continue
+			}
+			if rest[1] != 'u' || len(rest) < 6 {
+				return "", fmt.Errorf("invalid escape at offset %d", p.pos)
+			}
+			r, err := strconv.ParseUint(rest[2:6], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape at offset %d: %v", p.pos, err)
+			}
+			sb.WriteRune(rune(r))
+			p.pos += 6
+		default:
+			r, size := utf8.DecodeRuneInString(rest)
+			sb.WriteRune(r)
+			p.pos += size
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *hclTestParser) value() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, fmt.Errorf("expected a value at the end of the input")
+	}
+	switch p.src[p.pos] {
+	case '"':
+		return p.quoted()
+	case '{':
+		p.pos++
+		o := &hclTestObject{keys: []string{}, values: map[string]interface{}{}}
+		for {
+			p.skipSpace()
+			if strings.HasPrefix(p.src[p.pos:], "}") {
+				p.pos++
+				return o, nil
+			}
+			var key string
+			var err error
+			if strings.HasPrefix(p.src[p.pos:], `"`) {
+				key, err = p.quoted()
+			} else {
+				key, err = p.identifier()
+			}
+			if err != nil {
+				return nil, err
+			}
+			if _, found := o.values[key]; found {
+				return nil, fmt.Errorf("duplicate key %q", key)
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			o.keys = append(o.keys, key)
+			o.values[key] = v
+			// attributes are separated by newlines
+			if p.pos < len(p.src) && !strings.HasPrefix(strings.TrimLeft(p.src[p.pos:], " \t"), "\n") {
+				return nil, fmt.Errorf("expected a newline after %q at offset %d", key, p.pos)
+			}
+		}
+	case '[':
+		p.pos++
+		items := []interface{}{}
+		for {
+			p.skipSpace()
+			if strings.HasPrefix(p.src[p.pos:], "]") {
+				p.pos++
+				return items, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			p.skipSpace()
+			if strings.HasPrefix(p.src[p.pos:], ",") {
+				p.pos++
+			} else if !strings.HasPrefix(p.src[p.pos:], "]") {
+				return nil, fmt.Errorf("expected , or ] at offset %d", p.pos)
+			}
+		}
+	}
+	if match := regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?`).FindString(p.src[p.pos:]); match != "" {
+		p.pos += len(match)
+		return hclTestNumber(match), nil
+	}
+	word, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected %q; variables and functions aren't written", word)
+}
+
+// parses the HCL attribute and returns its name and value
+func parseHCLAttribute(t *testing.T, src string) (string, *hclTestObject) {
+	t.Helper()
+	p := hclTestParser{src: src}
+	name, err := p.identifier()
+	if err == nil {
+		err = p.expect("=")
+	}
+	var value interface{}
+	if err == nil {
+		value, err = p.value()
+	}
+	if err == nil {
+		if p.skipSpace(); p.pos != len(p.src) {
+			err = fmt.Errorf("unexpected %q after the attribute", p.src[p.pos:])
+		}
+	}
+	if err != nil {
+		t.Fatalf("parseHCLAttribute() error: %v\n%s", err, src)
+	}
+	o, ok := value.(*hclTestObject)
+	if !ok {
+		t.Fatalf("%s = %#v, want an object", name, value)
+	}
+	return name, o
+}
+
+func TestConvertRecommendationsToTFVar(t *testing.T) {
+	recos := hclTestRecommendations()
+	c := DensifyClient{}
+	src, err := c.ConvertRecommendationsToTFVar(&recos, "recos")
+	if err != nil {
+		t.Fatalf("ConvertRecommendationsToTFVar() error: %v", err)
+	}
+	name, value := parseHCLAttribute(t, src)
+	if name != "recos" {
+		t.Errorf("attribute = %s, want recos", name)
+	}
+
+	webKey := recos[0].Name
+	wantKeys := []string{"api", "prod/shop/deployment/cart", "prod/shop/deployment/cart/app", webKey}
+	if strings.Join(value.keys, "|") != strings.Join(wantKeys, "|") {
+		t.Errorf("keys = %q, want %q", value.keys, wantKeys)
+	}
+	attr := func(key string) *hclTestObject {
+		o, ok := value.values[key].(*hclTestObject)
+		if !ok {
+			t.Fatalf("%s = %#v, want an object", key, value.values[key])
+		}
+		return o
+	}
+
+	tests := []struct {
+		key   string
+		field string
+		want  interface{}
+	}{
+		{webKey, "name", recos[0].Name},
+		{webKey, "approvedType", "m5.large"},
+		{webKey, "predictedUptime", hclTestNumber("99.5")},
+		{webKey, "savingsEstimate", hclTestNumber("12.50")},
+		{"api", "minGroupCurrent", "1"},
+		{"api", "avgInstanceCountCurrent", hclTestNumber("2.5")},
+		{"prod/shop/deployment/cart/app", "currentCount", hclTestNumber("3")},
+		{"prod/shop/deployment/cart/app", "recommendedCpuRequest", hclTestNumber("250")},
+		{"prod/shop/deployment/cart/app", "recommendedMemLimit", hclTestNumber("512")},
+		{"prod/shop/deployment/cart/app", "estimatedSavings", hclTestNumber("12.50")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"."+tt.field, func(t *testing.T) {
+			if got := attr(tt.key).values[tt.field]; got != tt.want {
+				t.Errorf("%s = %#v, want %#v", tt.field, got, tt.want)
+			}
+		})
+	}
+
+	containers, ok := attr("prod/shop/deployment/cart").values["containers"].([]interface{})
+	if !ok || len(containers) != 1 {
+		t.Fatalf("containers = %#v, want a tuple with one container", attr("prod/shop/deployment/cart").values["containers"])
+	}
+	if container, ok := containers[0].(*hclTestObject); !ok || container.values["runningHours"] != hclTestNumber("720") {
+		t.Errorf("containers[0] = %#v, want runningHours 720", containers[0])
+	}
+	// fields that don't apply to a recommendation aren't written
+	if _, found := attr("api").values["cluster"]; found {
+		t.Errorf("cluster was written for a cloud recommendation:\n%s", src)
+	}
+	if _, found := attr("prod/shop/deployment/cart/app").values["currentType"]; found {
+		t.Errorf("currentType was written for a container:\n%s", src)
+	}
+}
+
+func TestConvertRecommendationsToTFVarName(t *testing.T) {
+	recos := hclTestRecommendations()
+	c := DensifyClient{}
+	tests := []struct {
+		varName string
+		want    string
+		hasErr  bool
+	}{
+		{"", DefaultTFVarName, false},
+		{"my_recos", "my_recos", false},
+		{"_recos-2", "_recos-2", false},
+		{"2recos", "", true},
+		{"my recos", "", true},
+		{`x = {} #`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.varName, func(t *testing.T) {
+			src, err := c.ConvertRecommendationsToTFVar(&recos, tt.varName)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("ConvertRecommendationsToTFVar(%q) = %s, want an error", tt.varName, src)
+				}
+				// the deprecated variant falls back to the default name
+				if name, _ := parseHCLAttribute(t, c.ConvertRecommendationsToTFWithVarName(&recos, tt.varName)); name != DefaultTFVarName {
+					t.Errorf("ConvertRecommendationsToTFWithVarName(%q) attribute = %s, want %s", tt.varName, name, DefaultTFVarName)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertRecommendationsToTFVar(%q) error: %v", tt.varName, err)
+			}
+			if name, _ := parseHCLAttribute(t, src); name != tt.want {
+				t.Errorf("ConvertRecommendationsToTFVar(%q) attribute = %s, want %s", tt.varName, name, tt.want)
+			}
+			if got := c.ConvertRecommendationsToTFWithVarName(&recos, tt.varName); got != src {
+				t.Errorf("ConvertRecommendationsToTFWithVarName(%q) =\n%s\nwant\n%s", tt.varName, got, src)
+			}
+		})
+	}
+}
+
+func TestHCLQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`plain`, `"plain"`},
+		{`a "b" c\d`, `"a \"b\" c\\d"`},
+		{"tab\tnew\nline\r", `"tab\tnew\nline\r"`},
+		{"bell\x07", `"bell\u0007"`},
+		{`${var.x}`, `"$${var.x}"`},
+		{`%{if x}`, `"%%{if x}"`},
+		{`$ 5 and 100%`, `"$ 5 and 100%"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := hclQuote(tt.in); got != tt.want {
+				t.Errorf("hclQuote(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+			// the quoted string parses back to the original
+			p := hclTestParser{src: hclQuote(tt.in)}
+			if got, err := p.quoted(); err != nil || got != tt.in {
+				t.Errorf("parsed value = %q, %v; want %q", got, err, tt.in)
+			}
+		})
+	}
+	// terraform would interpolate these, so the test parser rejects them
+	for _, src := range []string{`"${var.x}"`, `"%{if x}"`} {
+		p := hclTestParser{src: src}
+		if got, err := p.quoted(); err == nil {
+			t.Errorf("parsing %s = %q, want an error", src, got)
+		}
+	}
+}
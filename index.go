@@ -0,0 +1,112 @@
+package densify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the kind of identifier used to look up a cloud entity
+type SystemIdKind string
+
+const (
+	SystemIdName       SystemIdKind = "name"       // the entity name (default)
+	SystemIdEntityId   SystemIdKind = "entityid"   // the Densify entity id
+	SystemIdResourceId SystemIdKind = "resourceid" // the cloud resource id, ex. AWS instance id, Azure resource id or GCP self-link
+	SystemIdAny        SystemIdKind = "any"        // any of the above, in that order
+)
+
+// check the identifier kind is valid; empty means name
+func (k SystemIdKind) isValid() bool {
+	switch k {
+	case "", SystemIdName, SystemIdEntityId, SystemIdResourceId, SystemIdAny:
+		return true
+	default:
+		return false
+	}
+}
+
+// an index of recommendations by name, entity id and resource id, for fast repeated lookups
+type RecommendationIndex struct {
+	recos        []DensifyRecommendation
+	byName       map[string]int
+	byEntityId   map[string]int
+	byResourceId map[string]int
+}
+
+// normalizes an identifier so that lookups are case insensitive and ignore a trailing slash (ex. Azure resource ids and GCP self-links)
+func normalizeSystemId(id string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(id)), "/")
+}
+
+// Create an index of the recommendations by name, entity id and resource id. When identifiers are duplicated (ex. the same name in two
+// regions), the first recommendation in the list is returned, same as GetDensifyRecommendation.
+func NewRecommendationIndex(recos *[]DensifyRecommendation) *RecommendationIndex {
+	x := RecommendationIndex{
+		byName:       map[string]int{},
+		byEntityId:   map[string]int{},
+		byResourceId: map[string]int{},
+	}
+	if recos == nil {
+		return &x
+	}
+	x.recos = *recos
+
+	add := func(m map[string]int, id string, i int) {
+		id = normalizeSystemId(id)
+		if id == "" {
+			return
+		}
+		if _, ok := m[id]; !ok {
+			m[id] = i
+		}
+	}
+	for i := 0; i < len(x.recos); i++ {
+		add(x.byName, x.recos[i].Name, i)
+		add(x.byEntityId, x.recos[i].EntityId, i)
+		add(x.byResourceId, x.recos[i].ResourceId, i)
+	}
+	return &x
+}
+
+// returns the number of recommendations in the index
+func (x *RecommendationIndex) Length() int {
+	return len(x.recos)
+}
+
+// Look up a recommendation by the identifier kind; an empty kind looks up by name.
+func (x *RecommendationIndex) Lookup(kind SystemIdKind, id string) (*DensifyRecommendation, bool) {
+	id = normalizeSystemId(id)
+	var maps []map[string]int
+	switch kind {
+	case "", SystemIdName:
+		maps = []map[string]int{x.byName}
+	case SystemIdEntityId:
+		maps = []map[string]int{x.byEntityId}
+	case SystemIdResourceId:
+		maps = []map[string]int{x.byResourceId}
+	case SystemIdAny:
+		maps = []map[string]int{x.byName, x.byEntityId, x.byResourceId}
+	}
+	for i := 0; i < len(maps); i++ {
+		if j, ok := maps[i][id]; ok {
+			return &x.recos[j], true
+		}
+	}
+	return nil, false
+}
+
+// Pull the list of recommendations from the Densify API and index them by name, entity id and resource id.
+func (c *DensifyClient) GetDensifyRecommendationIndex() (*RecommendationIndex, error) {
+	// make sure a query has been defined
+	if c.Query == nil {
+		return nil, fmt.Errorf("you must specify a query first")
+	}
+	if c.Query.isKubernetesRequest() {
+		return nil, fmt.Errorf("recommendation indexes are only available for cloud queries")
+	}
+	recos, err := c.GetDensifyRecommendations()
+	if err != nil {
+		return nil, err
+	}
+	return NewRecommendationIndex(recos), nil
+}
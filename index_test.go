@@ -0,0 +1,115 @@
+package densify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecommendationIndexLookup(t *testing.T) {
+	recos := []DensifyRecommendation{
+		{Name: "Web-1", EntityId: "e1", ResourceId: "i-0abc", Region: "us-east-1"},
+		{Name: "web-1", EntityId: "e2", ResourceId: "i-0def", Region: "us-west-2"}, // the same name in another region
+		{Name: "db", EntityId: "E3", ResourceId: "/subscriptions/s1/virtualMachines/db/"},
+		{Name: "e1", EntityId: "e4"}, // a name that's another recommendation's entity id
+		{EntityId: "e5"},
+	}
+	x := NewRecommendationIndex(&recos)
+	tests := []struct {
+		name string
+		kind SystemIdKind
+		id   string
+		want string // the entity id found, or empty if none is
+	}{
+		{"name", SystemIdName, "db", "E3"},
+		{"empty kind is name", "", "db", "E3"},
+		{"name case", SystemIdName, " WEB-1 ", "e1"},
+		{"first duplicate wins", SystemIdName, "web-1", "e1"},
+		{"entity id", SystemIdEntityId, "e2", "e2"},
+		{"entity id case", SystemIdEntityId, "e3", "E3"},
+		{"resource id", SystemIdResourceId, "I-0DEF", "e2"},
+		{"resource id trailing slash", SystemIdResourceId, "/subscriptions/s1/virtualMachines/db", "E3"},
+		{"not a name", SystemIdName, "e2", ""},
+		{"not an entity id", SystemIdEntityId, "db", ""},
+		{"any by name first", SystemIdAny, "e1", "e4"},
+		{"any by entity id", SystemIdAny, "e2", "e2"},
+		{"any by resource id", SystemIdAny, "i-0abc", "e1"},
+		{"not found", SystemIdAny, "api", ""},
+		{"empty id", SystemIdAny, "", ""},
+		{"invalid kind", "tag", "db", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reco, found := x.Lookup(tt.kind, tt.id)
+			if found != (tt.want != "") {
+				t.Fatalf("Lookup(%s, %q) found = %v, want %v", tt.kind, tt.id, found, tt.want != "")
+			}
+			if found && reco.EntityId != tt.want {
+				t.Errorf("Lookup(%s, %q) = %s, want %s", tt.kind, tt.id, reco.EntityId, tt.want)
+			}
+		})
+	}
+	if x.Length() != len(recos) {
+		t.Errorf("Length() = %d, want %d", x.Length(), len(recos))
+	}
+
+	empty := NewRecommendationIndex(nil)
+	if _, found := empty.Lookup(SystemIdAny, "db"); found || empty.Length() != 0 {
+		t.Errorf("an index of nil recommendations isn't empty")
+	}
+}
+
+func TestSystemIdKindIsValid(t *testing.T) {
+	tests := []struct {
+		kind SystemIdKind
+		want bool
+	}{
+		{"", true},
+		{SystemIdName, true},
+		{SystemIdEntityId, true},
+		{SystemIdResourceId, true},
+		{SystemIdAny, true},
+		{"Name", false},
+		{"tag", false},
+	}
+	for _, tt := range tests {
+		if got := tt.kind.isValid(); got != tt.want {
+			t.Errorf("%q.isValid() = %v, want %v", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestGetDensifyRecommendationIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name":"web","entityId":"e1","resourceId":"i-0abc"}]`)
+	}))
+	defer server.Close()
+	tests := []struct {
+		name   string
+		query  *DensifyAPIQuery
+		hasErr bool
+	}{
+		{"cloud", &DensifyAPIQuery{AnalysisTechnology: "aws", AccountNumber: "111"}, false},
+		{"kubernetes", &DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod"}, true},
+		{"no query", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DensifyClient{HTTPClient: server.Client(), BaseURL: server.URL, AnalysisIds: []string{"a1"}, Query: tt.query}
+			x, err := c.GetDensifyRecommendationIndex()
+			if tt.hasErr {
+				if err == nil {
+					t.Errorf("GetDensifyRecommendationIndex() didn't return an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetDensifyRecommendationIndex() error: %v", err)
+			}
+			if reco, found := x.Lookup(SystemIdResourceId, "I-0ABC"); !found || reco.Name != "web" {
+				t.Errorf("Lookup() = %v, %v; want web", reco, found)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package densify
+
+import (
+	"fmt"
+)
+
+// how trustworthy a recommendation is
+type MaturityVerdict string
+
+const (
+	MaturityMature           MaturityVerdict = "Mature"           // all the policy checks passed
+	MaturityImmature         MaturityVerdict = "Immature"         // there is data, but not enough history to trust the recommendation yet
+	MaturityInsufficientData MaturityVerdict = "InsufficientData" // the recommendation has no audit or running history to evaluate
+)
+
+// The thresholds a recommendation must meet to be considered mature; zero values skip the check. Cloud recommendations are evaluated on
+// their audit info, how long the recommendation has been unchanged and their uptime. Containers are evaluated on how long the
+// recommendation has been unchanged and their running hours.
+type MaturityPolicy struct {
+	MinAuditCount    int64   // the minimum number of data collection audits (cloud)
+	MinSeenDays      int64   // the minimum number of days with workload data in the last 30 days (cloud)
+	MinSeenRatio     float64 // the minimum ratio of days with workload data to total days, 0-1 (cloud)
+	MinDaysUnchanged int64   // the minimum number of days the recommendation has been seen unchanged
+	MinUptimeRatio   float64 // the minimum ratio of hours running to total hours, 0-1 (cloud)
+	MinRunningHours  int64   // the minimum number of running hours (containers)
+}
+
+// returns a policy requiring two weeks of data and a recommendation that has been stable for a week
+func DefaultMaturityPolicy() MaturityPolicy {
+	return MaturityPolicy{
+		MinAuditCount:    14,
+		MinSeenDays:      14,
+		MinSeenRatio:     0.5,
+		MinDaysUnchanged: 7,
+		MinRunningHours:  14 * 24,
+	}
+}
+
+// the order of the verdicts from least to most mature
+var maturityVerdictOrder = map[MaturityVerdict]int{
+	MaturityInsufficientData: 0,
+	MaturityImmature:         1,
+	MaturityMature:           2,
+}
+
+// the result of evaluating a recommendation against a maturity policy
+type MaturityAssessment struct {
+	Verdict MaturityVerdict `json:"verdict"`
+	Reasons []string        `json:"reasons"` // why the recommendation isn't mature; empty when it is
+}
+
+// returns true if the recommendation passed the policy
+func (a *MaturityAssessment) IsMature() bool {
+	return a != nil && a.Verdict == MaturityMature
+}
+
+// collects the failed checks of an evaluation
+type maturityChecks struct {
+	reasons []string
+}
+
+func (m *maturityChecks) atLeast(name string, value int64, min int64) {
+	if min > 0 && value < min {
+		m.reasons = append(m.reasons, fmt.Sprintf("%s is %d; needs at least %d", name, value, min))
+	}
+}
+
+func (m *maturityChecks) ratioAtLeast(name string, value int64, total int64, min float64) {
+	if min <= 0 {
+		return
+	}
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(value) / float64(total)
+	}
+	if ratio < min {
+		m.reasons = append(m.reasons, fmt.Sprintf("%s is %.2f; needs at least %.2f", name, ratio, min))
+	}
+}
+
+func (m *maturityChecks) assessment() MaturityAssessment {
+	if len(m.reasons) == 0 {
+		return MaturityAssessment{Verdict: MaturityMature, Reasons: []string{}}
+	}
+	return MaturityAssessment{Verdict: MaturityImmature, Reasons: m.reasons}
+}
+
+// Evaluate a cloud recommendation; a kubernetes (container-level) recommendation is evaluated the same as its container.
+func (p *MaturityPolicy) Evaluate(r *DensifyRecommendation) MaturityAssessment {
+	if r.isKubernetes() {
+		return p.evaluateContainer(r.RecommSeenCount, r.RunningHours)
+	}
+
+	audit := r.AuditInfo.DataCollection
+	workload := r.AuditInfo.WorkloadData
+	if audit.AuditCount == 0 && workload.TotalDays == 0 && r.TotalHours == 0 {
+		return MaturityAssessment{Verdict: MaturityInsufficientData, Reasons: []string{"no audit or uptime history"}}
+	}
+	var checks maturityChecks
+	checks.atLeast("audit count", audit.AuditCount, p.MinAuditCount)
+	checks.atLeast("days with workload data", workload.SeenDays, p.MinSeenDays)
+	checks.ratioAtLeast("ratio of days with workload data", workload.SeenDays, workload.TotalDays, p.MinSeenRatio)
+	checks.atLeast("days unchanged", r.RecommSeenCount, p.MinDaysUnchanged)
+	checks.ratioAtLeast("uptime ratio", r.TotalHoursRunning, r.TotalHours, p.MinUptimeRatio)
+	return checks.assessment()
+}
+
+// Evaluate a single container's recommendation.
+func (p *MaturityPolicy) EvaluateContainer(c *DensifyContainerRecommendation) MaturityAssessment {
+	return p.evaluateContainer(c.DaysRecoUnchanged, c.RunningHours)
+}
+
+func (p *MaturityPolicy) evaluateContainer(daysUnchanged int64, runningHours int64) MaturityAssessment {
+	if daysUnchanged == 0 && runningHours == 0 {
+		return MaturityAssessment{Verdict: MaturityInsufficientData, Reasons: []string{"no running or recommendation history"}}
+	}
+	var checks maturityChecks
+	checks.atLeast("days unchanged", daysUnchanged, p.MinDaysUnchanged)
+	checks.atLeast("running hours", runningHours, p.MinRunningHours)
+	return checks.assessment()
+}
+
+// Evaluate the recommendation against the query's maturity policy and fall back to the query's fallback values if it isn't mature:
+// cloud recommendations get the fallback instance (or keep the current type) as the approved type, and containers that aren't mature
+// use their fallback requests and limits in GetEffectiveResources. The assessments are stored in the recommendation and each container;
+// a pod-level kubernetes recommendation is as mature as its least mature container, with the reasons of each container that isn't mature.
+func (q *DensifyAPIQuery) applyMaturityPolicy(reco *DensifyRecommendation) {
+	if q.MaturityPolicy == nil {
+		return
+	}
+	if !q.isKubernetesRequest() {
+		assessment := q.MaturityPolicy.Evaluate(reco)
+		reco.Maturity = &assessment
+		if !assessment.IsMature() {
+			reco.ApprovedType = reco.CurrentType
+			if q.FallbackInstance != "" {
+				reco.ApprovedType = q.FallbackInstance
+			}
+		}
+		return
+	}
+
+	pod := MaturityAssessment{Verdict: MaturityMature, Reasons: []string{}}
+	if len(reco.Containers) == 0 {
+		pod = MaturityAssessment{Verdict: MaturityInsufficientData, Reasons: []string{"no containers"}}
+	}
+	for i := 0; i < len(reco.Containers); i++ {
+		assessment := q.MaturityPolicy.EvaluateContainer(&reco.Containers[i])
+		reco.Containers[i].Maturity = &assessment
+		if maturityVerdictOrder[assessment.Verdict] < maturityVerdictOrder[pod.Verdict] {
+			pod.Verdict = assessment.Verdict
+		}
+		for j := 0; j < len(assessment.Reasons); j++ {
+			pod.Reasons = append(pod.Reasons, fmt.Sprintf("container %s: %s", reco.Containers[i].Container, assessment.Reasons[j]))
+		}
+	}
+	reco.Maturity = &pod
+}
@@ -0,0 +1,153 @@
+package densify
+
+import (
+	"strings"
+	"testing"
+)
+
+func maturityTestCloud(auditCount int64, seenDays int64, totalDays int64, daysUnchanged int64, hoursRunning int64, totalHours int64) *DensifyRecommendation {
+	r := &DensifyRecommendation{Name: "web", CurrentType: "m5.xlarge", RecommendedType: "m5.large", ApprovedType: "m5.large",
+		RecommSeenCount: daysUnchanged, TotalHoursRunning: hoursRunning, TotalHours: totalHours}
+	r.AuditInfo.DataCollection.AuditCount = auditCount
+	r.AuditInfo.WorkloadData.SeenDays = seenDays
+	r.AuditInfo.WorkloadData.TotalDays = totalDays
+	return r
+}
+
+func TestMaturityPolicyEvaluate(t *testing.T) {
+	policy := DefaultMaturityPolicy()
+	policy.MinUptimeRatio = 0.5
+	tests := []struct {
+		name    string
+		reco    *DensifyRecommendation
+		verdict MaturityVerdict
+		reasons string
+	}{
+		{"mature", maturityTestCloud(30, 28, 30, 10, 700, 720), MaturityMature, ""},
+		{"no history", maturityTestCloud(0, 0, 0, 10, 0, 0), MaturityInsufficientData, "no audit or uptime history"},
+		{"too few audits", maturityTestCloud(5, 28, 30, 10, 700, 720), MaturityImmature, "audit count is 5; needs at least 14"},
+		{"too few days seen", maturityTestCloud(30, 10, 30, 10, 700, 720), MaturityImmature,
+			"days with workload data is 10; needs at least 14|ratio of days with workload data is 0.33; needs at least 0.50"},
+		{"recently changed", maturityTestCloud(30, 28, 30, 3, 700, 720), MaturityImmature, "days unchanged is 3; needs at least 7"},
+		{"mostly stopped", maturityTestCloud(30, 28, 30, 10, 100, 720), MaturityImmature, "uptime ratio is 0.14; needs at least 0.50"},
+		{"no uptime history", maturityTestCloud(30, 28, 30, 10, 0, 0), MaturityImmature, "uptime ratio is 0.00; needs at least 0.50"},
+		{"container", &DensifyRecommendation{Namespace: "shop", PodService: "cart", Container: "app", RecommSeenCount: 3, RunningHours: 500},
+			MaturityImmature, "days unchanged is 3; needs at least 7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Evaluate(tt.reco)
+			if got.Verdict != tt.verdict || strings.Join(got.Reasons, "|") != tt.reasons {
+				t.Errorf("Evaluate() = %s %q, want %s %q", got.Verdict, got.Reasons, tt.verdict, tt.reasons)
+			}
+			if got.IsMature() != (tt.verdict == MaturityMature) {
+				t.Errorf("IsMature() = %v", got.IsMature())
+			}
+		})
+	}
+
+	// zero thresholds skip the checks
+	var none MaturityPolicy
+	if got := none.Evaluate(maturityTestCloud(1, 1, 30, 1, 1, 720)); !got.IsMature() || got.Reasons == nil {
+		t.Errorf("Evaluate() with an empty policy = %+v, want mature", got)
+	}
+	var nilAssessment *MaturityAssessment
+	if nilAssessment.IsMature() {
+		t.Errorf("a nil assessment is mature")
+	}
+}
+
+func TestMaturityPolicyEvaluateContainer(t *testing.T) {
+	policy := DefaultMaturityPolicy()
+	tests := []struct {
+		name      string
+		container DensifyContainerRecommendation
+		verdict   MaturityVerdict
+		reasons   string
+	}{
+		{"mature", DensifyContainerRecommendation{DaysRecoUnchanged: 7, RunningHours: 336}, MaturityMature, ""},
+		{"no history", DensifyContainerRecommendation{}, MaturityInsufficientData, "no running or recommendation history"},
+		{"new", DensifyContainerRecommendation{DaysRecoUnchanged: 1, RunningHours: 24}, MaturityImmature,
+			"days unchanged is 1; needs at least 7|running hours is 24; needs at least 336"},
+		{"not running long", DensifyContainerRecommendation{DaysRecoUnchanged: 14, RunningHours: 100}, MaturityImmature, "running hours is 100; needs at least 336"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.EvaluateContainer(&tt.container)
+			if got.Verdict != tt.verdict || strings.Join(got.Reasons, "|") != tt.reasons {
+				t.Errorf("EvaluateContainer() = %s %q, want %s %q", got.Verdict, got.Reasons, tt.verdict, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestApplyMaturityPolicy(t *testing.T) {
+	policy := DefaultMaturityPolicy()
+	cloud := []struct {
+		name     string
+		query    DensifyAPIQuery
+		reco     *DensifyRecommendation
+		approved string
+	}{
+		{"no policy", DensifyAPIQuery{AnalysisTechnology: "aws"}, maturityTestCloud(1, 1, 30, 1, 1, 720), "m5.large"},
+		{"mature", DensifyAPIQuery{AnalysisTechnology: "aws", MaturityPolicy: &policy}, maturityTestCloud(30, 28, 30, 10, 700, 720), "m5.large"},
+		{"keeps the current type", DensifyAPIQuery{AnalysisTechnology: "aws", MaturityPolicy: &policy}, maturityTestCloud(1, 1, 30, 1, 1, 720), "m5.xlarge"},
+		{"fallback instance", DensifyAPIQuery{AnalysisTechnology: "aws", MaturityPolicy: &policy, FallbackInstance: "t3.large"},
+			maturityTestCloud(1, 1, 30, 1, 1, 720), "t3.large"},
+	}
+	for _, tt := range cloud {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.applyMaturityPolicy(tt.reco)
+			if tt.reco.ApprovedType != tt.approved {
+				t.Errorf("ApprovedType = %s, want %s", tt.reco.ApprovedType, tt.approved)
+			}
+			if (tt.reco.Maturity == nil) != (tt.query.MaturityPolicy == nil) {
+				t.Errorf("Maturity = %+v", tt.reco.Maturity)
+			}
+		})
+	}
+
+	k8s := DensifyAPIQuery{AnalysisTechnology: "k8s", MaturityPolicy: &policy}
+	pods := []struct {
+		name       string
+		containers []DensifyContainerRecommendation
+		verdict    MaturityVerdict
+		reasons    string
+	}{
+		{"all mature", []DensifyContainerRecommendation{
+			{Container: "web", DaysRecoUnchanged: 7, RunningHours: 336},
+			{Container: "sidecar", DaysRecoUnchanged: 30, RunningHours: 720},
+		}, MaturityMature, ""},
+		{"least mature container", []DensifyContainerRecommendation{
+			{Container: "web", DaysRecoUnchanged: 7, RunningHours: 336},
+			{Container: "sidecar", DaysRecoUnchanged: 2, RunningHours: 720},
+		}, MaturityImmature, "container sidecar: days unchanged is 2; needs at least 7"},
+		{"insufficient data", []DensifyContainerRecommendation{
+			{Container: "web", DaysRecoUnchanged: 2, RunningHours: 720},
+			{Container: "init"},
+		}, MaturityInsufficientData, "container web: days unchanged is 2; needs at least 7|container init: no running or recommendation history"},
+		{"no containers", nil, MaturityInsufficientData, "no containers"},
+	}
+	for _, tt := range pods {
+		t.Run(tt.name, func(t *testing.T) {
+			reco := DensifyRecommendation{Namespace: "shop", PodService: "cart", ApprovedType: "na", Containers: tt.containers}
+			k8s.applyMaturityPolicy(&reco)
+			if reco.Maturity == nil {
+				t.Fatalf("Maturity isn't set")
+			}
+			if reco.Maturity.Verdict != tt.verdict || strings.Join(reco.Maturity.Reasons, "|") != tt.reasons {
+				t.Errorf("Maturity = %s %q, want %s %q", reco.Maturity.Verdict, reco.Maturity.Reasons, tt.verdict, tt.reasons)
+			}
+			for i := 0; i < len(reco.Containers); i++ {
+				want := policy.EvaluateContainer(&reco.Containers[i])
+				if got := reco.Containers[i].Maturity; got == nil || got.Verdict != want.Verdict {
+					t.Errorf("Containers[%d].Maturity = %+v, want %s", i, got, want.Verdict)
+				}
+			}
+			// the approved type is only for cloud recommendations
+			if reco.ApprovedType != "na" {
+				t.Errorf("ApprovedType = %s, want it unchanged", reco.ApprovedType)
+			}
+		})
+	}
+}
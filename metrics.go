@@ -0,0 +1,297 @@
+package densify
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the content type of the OpenMetrics text format
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// the type of a metric family
+type MetricType string
+
+const (
+	MetricGauge MetricType = "gauge"
+)
+
+// a metric label
+type MetricLabel struct {
+	Name  string
+	Value string
+}
+
+// a sample of a metric family: its labels (labels with empty values are left out) and value
+type MetricSample struct {
+	Labels []MetricLabel
+	Value  float64
+}
+
+// a metric family, ex. densify_container_cpu_request_cores, and its samples
+type MetricFamily struct {
+	Name    string
+	Type    MetricType
+	Unit    string // the unit the name ends with, ex. seconds; optional
+	Help    string
+	Samples []MetricSample
+}
+
+// the metric families collected from recommendations, in the order they're written
+const (
+	metricCpuRequest      = "densify_container_cpu_request_cores"
+	metricCpuLimit        = "densify_container_cpu_limit_cores"
+	metricMemRequest      = "densify_container_memory_request_bytes"
+	metricMemLimit        = "densify_container_memory_limit_bytes"
+	metricMonthlyCost     = "densify_monthly_cost"
+	metricMonthlySavings  = "densify_savings_estimate_monthly"
+	metricAge             = "densify_recommendation_age_seconds"
+	metricGuardrailsCount = "densify_guardrails_targets"
+)
+
+func newMetricFamilies() []MetricFamily {
+	return []MetricFamily{
+		{Name: metricCpuRequest, Type: MetricGauge, Unit: "cores", Help: "The container's CPU request; sizing is current or recommended."},
+		{Name: metricCpuLimit, Type: MetricGauge, Unit: "cores", Help: "The container's CPU limit; sizing is current or recommended."},
+		{Name: metricMemRequest, Type: MetricGauge, Unit: "bytes", Help: "The container's memory request; sizing is current or recommended."},
+		{Name: metricMemLimit, Type: MetricGauge, Unit: "bytes", Help: "The container's memory limit; sizing is current or recommended."},
+		{Name: metricMonthlyCost, Type: MetricGauge, Help: "The monthly cost; sizing is current or recommended."},
+		{Name: metricMonthlySavings, Type: MetricGauge, Help: "The estimated monthly savings of the recommendation."},
+		{Name: metricAge, Type: MetricGauge, Unit: "seconds", Help: "How long ago Densify first made the recommendation, or how long a container's recommendation has been unchanged."},
+		{Name: metricGuardrailsCount, Type: MetricGauge, Help: "The number of guardrails target instance types, by compatibility."},
+	}
+}
+
+// returns the labels that identify a recommendation: account, region, cluster, namespace, controller_type, workload and, for cloud
+// instances, name and entity_id
+func (r *DensifyRecommendation) metricLabels() []MetricLabel {
+	labels := []MetricLabel{{"account", r.AccountId}, {"region", r.Region}}
+	if r.isKubernetes() {
+		k := r.WorkloadKey()
+		return append(labels, MetricLabel{"cluster", k.Cluster}, MetricLabel{"namespace", k.Namespace},
+			MetricLabel{"controller_type", k.ControllerType}, MetricLabel{"workload", k.Name})
+	}
+	return append(labels, MetricLabel{"name", r.Name}, MetricLabel{"entity_id", r.EntityId})
+}
+
+// returns the labels with more labels added
+func withLabels(labels []MetricLabel, more ...MetricLabel) []MetricLabel {
+	all := make([]MetricLabel, 0, len(labels)+len(more))
+	all = append(all, labels...)
+	return append(all, more...)
+}
+
+// Collect metric families from (container- or pod-level) recommendations: each container's current and recommended CPU and memory
+// requests and limits, each recommendation's current and recommended monthly cost and savings estimate (with a currency label), how long
+// ago it was first made (or, for containers, how many days it has been unchanged), and the number of guardrails targets by compatibility.
+// Values that aren't set are left out. The ages are relative to now.
+func CollectMetrics(recos *[]DensifyRecommendation, now time.Time) []MetricFamily {
+	families := newMetricFamilies()
+	index := map[string]*MetricFamily{}
+	for i := 0; i < len(families); i++ {
+		index[families[i].Name] = &families[i]
+	}
+	add := func(name string, labels []MetricLabel, value float64) {
+		index[name].Samples = append(index[name].Samples, MetricSample{Labels: labels, Value: value})
+	}
+	addMoney := func(name string, labels []MetricLabel, value Money) {
+		if !value.IsZero() {
+			add(name, withLabels(labels, MetricLabel{"currency", value.CurrencyCode()}), value.Monthly().Float64())
+		}
+	}
+	addCpu := func(name string, labels []MetricLabel, current CpuQuantity, recommended CpuQuantity) {
+		if !current.IsZero() {
+			add(name, withLabels(labels, MetricLabel{"sizing", "current"}), current.Cores())
+		}
+		if !recommended.IsZero() {
+			add(name, withLabels(labels, MetricLabel{"sizing", "recommended"}), recommended.Cores())
+		}
+	}
+	addMem := func(name string, labels []MetricLabel, current MemQuantity, recommended MemQuantity) {
+		if !current.IsZero() {
+			add(name, withLabels(labels, MetricLabel{"sizing", "current"}), float64(current.Bytes()))
+		}
+		if !recommended.IsZero() {
+			add(name, withLabels(labels, MetricLabel{"sizing", "recommended"}), float64(recommended.Bytes()))
+		}
+	}
+
+	for i := 0; recos != nil && i < len(*recos); i++ {
+		r := &(*recos)[i]
+		labels := r.metricLabels()
+		recoLabels := labels
+		if r.isKubernetes() && r.Container != "" {
+			recoLabels = withLabels(labels, MetricLabel{"container", r.ContainerKey().Container})
+		}
+
+		addMoney(metricMonthlyCost, withLabels(recoLabels, MetricLabel{"sizing", "current"}), r.CurrentCost)
+		addMoney(metricMonthlyCost, withLabels(recoLabels, MetricLabel{"sizing", "recommended"}), r.RecommendedCost)
+		addMoney(metricMonthlySavings, recoLabels, r.MonthlySavings())
+		if !r.RecommFirstSeen.IsZero() {
+			add(metricAge, recoLabels, now.Sub(r.RecommFirstSeen.Time()).Seconds())
+		}
+
+		if r.isKubernetes() {
+			pod := r.asPod()
+			for j := 0; j < len(pod.Containers); j++ {
+				c := &pod.Containers[j]
+				containerLabels := withLabels(labels, MetricLabel{"container", normalizeKeyPart(c.Container)})
+				addCpu(metricCpuRequest, containerLabels, c.CurrentCpuRequest, c.RecommendedCpuRequest)
+				addCpu(metricCpuLimit, containerLabels, c.CurrentCpuLimit, c.RecommendedCpuLimit)
+				addMem(metricMemRequest, containerLabels, c.CurrentMemRequest, c.RecommendedMemRequest)
+				addMem(metricMemLimit, containerLabels, c.CurrentMemLimit, c.RecommendedMemLimit)
+				if r.RecommFirstSeen.IsZero() && c.DaysRecoUnchanged > 0 {
+					add(metricAge, containerLabels, (time.Duration(c.DaysRecoUnchanged) * day).Seconds())
+				}
+			}
+		}
+
+		counts := map[Compatibility]int{}
+		for j := 0; j < len(r.Guardrails.Targets); j++ {
+			counts[r.Guardrails.Targets[j].Compatibility]++
+		}
+		compatibilities := make([]string, 0, len(counts))
+		for c := range counts {
+			compatibilities = append(compatibilities, string(c))
+		}
+		sort.Strings(compatibilities)
+		for j := 0; j < len(compatibilities); j++ {
+			add(metricGuardrailsCount, withLabels(recoLabels, MetricLabel{"compatibility", compatibilities[j]}), float64(counts[Compatibility(compatibilities[j])]))
+		}
+	}
+	return families
+}
+
+// escapes a label value or help text for the OpenMetrics text format
+func openMetricsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formats a sample value for the OpenMetrics text format
+func openMetricsValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// Write metric families in the OpenMetrics text format (see OpenMetricsContentType), ending with "# EOF". Families without samples are
+// left out. If a family has more than one sample with the same labels, only the first is written, since the exposition would be invalid
+// otherwise.
+func WriteOpenMetrics(w io.Writer, families []MetricFamily) error {
+	var sb strings.Builder
+	for i := 0; i < len(families); i++ {
+		f := &families[i]
+		if len(f.Samples) == 0 {
+			continue
+		}
+		if f.Unit != "" && !strings.HasSuffix(f.Name, "_"+f.Unit) {
+			return fmt.Errorf("metric '%s' must end with its unit '%s'", f.Name, f.Unit)
+		}
+		sb.WriteString("# TYPE " + f.Name + " " + string(f.Type) + "\n")
+		if f.Unit != "" {
+			sb.WriteString("# UNIT " + f.Name + " " + f.Unit + "\n")
+		}
+		if f.Help != "" {
+			sb.WriteString("# HELP " + f.Name + " " + openMetricsEscape(f.Help) + "\n")
+		}
+		seen := map[string]bool{}
+		for j := 0; j < len(f.Samples); j++ {
+			var labels strings.Builder
+			for k := 0; k < len(f.Samples[j].Labels); k++ {
+				label := f.Samples[j].Labels[k]
+				if label.Value == "" {
+					continue
+				}
+				if labels.Len() > 0 {
+					labels.WriteString(",")
+				}
+				labels.WriteString(label.Name + "=\"" + openMetricsEscape(label.Value) + "\"")
+			}
+			series := f.Name
+			if labels.Len() > 0 {
+				series += "{" + labels.String() + "}"
+			}
+			if seen[series] {
+				continue
+			}
+			seen[series] = true
+			sb.WriteString(series + " " + openMetricsValue(f.Samples[j].Value) + "\n")
+		}
+	}
+	sb.WriteString("# EOF\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Collects metrics from recommendations on each scrape, and serves them in the OpenMetrics text format as an http.Handler, ex. on
+// /metrics, without a dependency on the Prometheus client.
+type MetricsCollector struct {
+	Load     func() (*[]DensifyRecommendation, error) // loads the recommendations, ex. the client's GetDensifyRecommendations
+	CacheTTL time.Duration                            // how long loaded recommendations are reused, so scrapes don't load them each time; zero loads them on every scrape
+	Now      func() time.Time                         // the time recommendation ages are relative to; defaults to time.Now
+
+	mu       sync.Mutex
+	recos    *[]DensifyRecommendation
+	loadedAt time.Time
+}
+
+// Create a collector that loads the recommendations with load, ex. client.GetDensifyRecommendations.
+func NewMetricsCollector(load func() (*[]DensifyRecommendation, error)) *MetricsCollector {
+	return &MetricsCollector{Load: load}
+}
+
+// Collect the metric families (see CollectMetrics), loading the recommendations if they aren't cached. The recommendations are loaded
+// without holding the collector's lock, so a slow load doesn't block other scrapes; concurrent scrapes can each load them.
+func (c *MetricsCollector) Collect() ([]MetricFamily, error) {
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+	c.mu.Lock()
+	recos, loadedAt := c.recos, c.loadedAt
+	c.mu.Unlock()
+	if recos == nil || c.CacheTTL <= 0 || now().Sub(loadedAt) >= c.CacheTTL {
+		if c.Load == nil {
+			return nil, fmt.Errorf("metrics collector has no function to load recommendations")
+		}
+		loaded, err := c.Load()
+		if err != nil {
+			return nil, err
+		}
+		recos = loaded
+		c.mu.Lock()
+		c.recos, c.loadedAt = recos, now()
+		c.mu.Unlock()
+	}
+	return CollectMetrics(recos, now()), nil
+}
+
+func (c *MetricsCollector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	families, err := c.Collect()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error collecting Densify metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+	var sb strings.Builder
+	if err := WriteOpenMetrics(&sb, families); err != nil {
+		http.Error(w, fmt.Sprintf("error writing Densify metrics: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", OpenMetricsContentType)
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		// the response can't be completed, ex. the scraper disconnected; abort it so it isn't taken as all the metrics
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,218 @@
+package densify
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOpenMetricsValue(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, "0"},
+		{0.25, "0.25"},
+		{536870912, "536870912"},
+		{-3.5, "-3.5"},
+		{math.NaN(), "NaN"},
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+	}
+	for _, tt := range tests {
+		if got := openMetricsValue(tt.value); got != tt.want {
+			t.Errorf("openMetricsValue(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		families []MetricFamily
+		want     string
+		hasErr   bool
+	}{
+		{"escaping and empty labels", []MetricFamily{
+			{Name: "test_bytes", Type: MetricGauge, Unit: "bytes", Help: "A \\ help\ntext.", Samples: []MetricSample{
+				{Labels: []MetricLabel{{"name", `a "b"`}, {"region", ""}}, Value: 1024},
+				{Value: 1},
+			}},
+		}, "# TYPE test_bytes gauge\n# UNIT test_bytes bytes\n# HELP test_bytes A \\\\ help\\ntext.\ntest_bytes{name=\"a \\\"b\\\"\"} 1024\ntest_bytes 1\n# EOF\n", false},
+		{"duplicate series keep the first", []MetricFamily{
+			{Name: "test", Type: MetricGauge, Samples: []MetricSample{
+				{Labels: []MetricLabel{{"name", "a"}}, Value: 1},
+				{Labels: []MetricLabel{{"name", "a"}, {"region", ""}}, Value: 2},
+				{Labels: []MetricLabel{{"name", "b"}}, Value: 3},
+			}},
+		}, "# TYPE test gauge\ntest{name=\"a\"} 1\ntest{name=\"b\"} 3\n# EOF\n", false},
+		{"families without samples", []MetricFamily{{Name: "test", Type: MetricGauge}}, "# EOF\n", false},
+		{"name without its unit", []MetricFamily{{Name: "test", Type: MetricGauge, Unit: "seconds", Samples: []MetricSample{{Value: 1}}}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			err := WriteOpenMetrics(&sb, tt.families)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("WriteOpenMetrics() = %s, want an error", sb.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WriteOpenMetrics() error: %v", err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("WriteOpenMetrics() =\n%s\nwant\n%s", sb.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectMetrics(t *testing.T) {
+	recos := renderTestRecommendations()
+	recos = append(recos, patchTestRecommendation("deployment"))
+	var sb strings.Builder
+	if err := WriteOpenMetrics(&sb, CollectMetrics(&recos, time.Now())); err != nil {
+		t.Fatalf("WriteOpenMetrics() error: %v", err)
+	}
+	out := sb.String()
+	wants := []string{
+		`densify_container_cpu_request_cores{region="us-east-1",cluster="prod",namespace="shop",controller_type="deployment",workload="cart",container="app",sizing="current"} 0.5`,
+		`densify_container_memory_request_bytes{region="us-east-1",cluster="prod",namespace="shop",controller_type="deployment",workload="cart",container="app",sizing="recommended"} 536870912`,
+		`densify_container_cpu_request_cores{cluster="prod",namespace="shop",controller_type="deployment",workload="cart",container="web",sizing="recommended"} 0.25`,
+		`densify_container_memory_limit_bytes{cluster="prod",namespace="shop",controller_type="deployment",workload="cart",container="web",sizing="recommended"} 536870912`,
+		`densify_savings_estimate_monthly{region="us-east-1",name="web|prod",entity_id="e1",currency="USD"} 40`,
+		`densify_guardrails_targets{region="us-west-2",name="api-1",entity_id="e2",compatibility="OK"} 1`,
+	}
+	for i := 0; i < len(wants); i++ {
+		if !strings.Contains(out, wants[i]+"\n") {
+			t.Errorf("metrics have no %s:\n%s", wants[i], out)
+		}
+	}
+	// the fallback values aren't current or recommended sizing, so they're left out
+	if strings.Contains(out, `container="init"`) {
+		t.Errorf("metrics have the init container:\n%s", out)
+	}
+}
+
+func TestMetricsCollector(t *testing.T) {
+	recos := renderTestRecommendations()
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	loads := 0
+	collector := NewMetricsCollector(func() (*[]DensifyRecommendation, error) {
+		loads++
+		return &recos, nil
+	})
+	collector.CacheTTL = time.Minute
+	collector.Now = func() time.Time { return now }
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	scrape := func() string {
+		t.Helper()
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != OpenMetricsContentType {
+			t.Fatalf("scrape = %d %s: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+		}
+		return string(body)
+	}
+	tests := []struct {
+		name    string
+		advance time.Duration
+		loads   int
+	}{
+		{"first scrape", 0, 1},
+		{"cached", 30 * time.Second, 1},
+		{"expired", time.Minute, 2},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+		if body := scrape(); !strings.HasSuffix(body, "# EOF\n") {
+			t.Errorf("%s: body =\n%s", tt.name, body)
+		}
+		if loads != tt.loads {
+			t.Errorf("%s: %d loads, want %d", tt.name, loads, tt.loads)
+		}
+	}
+
+	failing := NewMetricsCollector(func() (*[]DensifyRecommendation, error) {
+		return nil, errors.New("unauthorized")
+	})
+	rec := httptest.NewRecorder()
+	failing.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "unauthorized") {
+		t.Errorf("failing scrape = %d %s", rec.Code, rec.Body.String())
+	}
+	if _, err := (&MetricsCollector{}).Collect(); err == nil {
+		t.Errorf("Collect() without a load function didn't return an error")
+	}
+}
+
+func TestMetricsCollectorConcurrentScrapes(t *testing.T) {
+	recos := renderTestRecommendations()
+	var mu sync.Mutex
+	loads := 0
+	started := make(chan bool)
+	release := make(chan bool)
+	collector := NewMetricsCollector(func() (*[]DensifyRecommendation, error) {
+		mu.Lock()
+		loads++
+		first := loads == 1
+		mu.Unlock()
+		if first {
+			// the first load is slow, and mustn't block the other scrapes
+			started <- true
+			<-release
+		}
+		return &recos, nil
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := collector.Collect()
+		done <- err
+	}()
+	<-started
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := collector.Collect()
+			errs <- err
+		}()
+	}
+	waited := make(chan bool)
+	go func() {
+		wg.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("scrapes were blocked by a slow load")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Collect() error: %v", err)
+	}
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Collect() error: %v", err)
+		}
+	}
+}
@@ -3,22 +3,24 @@ package densify
 import "fmt"
 
 type FloatType float64
-type Currency float32
 
 func ConvertFloatToStr(value interface{}) string {
 	return fmt.Sprintf("%f", value)
 }
 
 type DensifyAnalysis struct {
-	AccountId        string `json:"accountId"`
-	AccountName      string `json:"accountName"`
-	AnalysisId       string `json:"analysisId"`
-	AnalysisName     string `json:"analysisName"`
-	Href             string `json:"href"`
-	AnalysisStatus   string `json:"analysisStatus"`
-	AnalysisResults  string `json:"analysisResults"`
-	PolicyName       string `json:"policyName"`
-	PolicyInstanceId string `json:"policyInstanceId"`
+	AccountId         string      `json:"accountId"`
+	AccountName       string      `json:"accountName"`
+	AnalysisId        string      `json:"analysisId"`
+	AnalysisName      string      `json:"analysisName"`
+	Href              string      `json:"href"`
+	AnalysisStatus    string      `json:"analysisStatus"`
+	AnalysisResults   string      `json:"analysisResults"`
+	AnalysisCompleted EpochMillis `json:"analysisCompleted"` // epoch (ms) of when the analysis last completed; zero if it never completed
+	PolicyName        string      `json:"policyName"`
+	PolicyInstanceId  string      `json:"policyInstanceId"`
+
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
 type DensifyRecommendation struct {
@@ -35,34 +37,34 @@ type DensifyRecommendation struct {
 	// returned by Densify API
 	// Cloud
 
-	EntityId                string    `json:"entityId"`
-	ResourceId              string    `json:"resourceId"`
-	AccountIdRef            string    `json:"accountIdRef"`
-	Region                  string    `json:"region"`
-	CurrentType             string    `json:"currentType"`
-	RecommendationType      string    `json:"recommendationType"`
-	RecommendedType         string    `json:"recommendedType"`
-	ImplementationMethod    string    `json:"implementationMethod"`
-	PredictedUptime         FloatType `json:"predictedUptime"`
-	TotalHoursRunning       int64     `json:"totalHoursRunning"`
-	TotalHours              int64     `json:"totalHours"`
-	Name                    string    `json:"name"`
-	RptHref                 string    `json:"rptHref"`
-	ApprovalType            string    `json:"approvalType"`
-	DensifyPolicy           string    `json:"densifyPolicy"`
-	SavingsEstimate         Currency  `json:"savingsEstimate"`
-	EffortEstimate          string    `json:"effortEstimate"`
-	PowerState              string    `json:"powerState"`
-	RecommendedHostEntityId string    `json:"recommendedHostEntityId"`
-	CurrentCost             Currency  `json:"currentCost"`
-	RecommendedCost         Currency  `json:"recommendedCost"`
-	ServiceType             string    `json:"serviceType"`
-	CurrentHourlyRate       FloatType `json:"currentHourlyRate"`
-	RecommendedHourlyRate   FloatType `json:"recommendedHourlyRate"`
-	RecommFirstSeen         int64     `json:"recommFirstSeen"`
-	RecommLastSeen          int64     `json:"recommLastSeen"`
-	RecommSeenCount         int64     `json:"recommSeenCount"`
-	AuditInfo               AuditInfo `json:"auditInfo"`
+	EntityId                string             `json:"entityId"`
+	ResourceId              string             `json:"resourceId"`
+	AccountIdRef            string             `json:"accountIdRef"`
+	Region                  string             `json:"region"`
+	CurrentType             string             `json:"currentType"`
+	RecommendationType      RecommendationType `json:"recommendationType"`
+	RecommendedType         string             `json:"recommendedType"`
+	ImplementationMethod    string             `json:"implementationMethod"`
+	PredictedUptime         FloatType          `json:"predictedUptime"`
+	TotalHoursRunning       int64              `json:"totalHoursRunning"`
+	TotalHours              int64              `json:"totalHours"`
+	Name                    string             `json:"name"`
+	RptHref                 string             `json:"rptHref"`
+	ApprovalType            ApprovalType       `json:"approvalType"`
+	DensifyPolicy           string             `json:"densifyPolicy"`
+	SavingsEstimate         Money              `json:"savingsEstimate"`
+	EffortEstimate          EffortEstimate     `json:"effortEstimate"`
+	PowerState              PowerState         `json:"powerState"`
+	RecommendedHostEntityId string             `json:"recommendedHostEntityId"`
+	CurrentCost             Money              `json:"currentCost"`
+	RecommendedCost         Money              `json:"recommendedCost"`
+	ServiceType             string             `json:"serviceType"`
+	CurrentHourlyRate       Money              `json:"currentHourlyRate"`     // hourly
+	RecommendedHourlyRate   Money              `json:"recommendedHourlyRate"` // hourly
+	RecommFirstSeen         EpochMillis        `json:"recommFirstSeen"`
+	RecommLastSeen          EpochMillis        `json:"recommLastSeen"`
+	RecommSeenCount         int64              `json:"recommSeenCount"`
+	AuditInfo               AuditInfo          `json:"auditInfo"`
 
 	// ASG specific values
 	MinGroupCurrent             string    `json:"minGroupCurrent"`
@@ -73,77 +75,90 @@ type DensifyRecommendation struct {
 	AvgInstanceCountRecommended FloatType `json:"avgInstanceCountRecommended"`
 	AvgInstanceCountCurrent     FloatType `json:"avgInstanceCountCurrent"`
 
-	// Container values
-	Container             string    `json:"container"`
-	Cluster               string    `json:"cluster"`
-	HostName              string    `json:"hostName"`
-	EstimatedSavings      FloatType `json:"estimatedSavings"`
-	TotalNetSavings       FloatType `json:"totalNetSavings"`
-	DisplayName           string    `json:"displayName"`
-	PodService            string    `json:"podService"`
-	CurrentCount          int64     `json:"currentCount"`
-	CurrentCpuRequest     int64     `json:"currentCpuRequest"`
-	CurrentCpuLimit       int64     `json:"currentCpuLimit"`
-	CurrentMemRequest     int64     `json:"currentMemRequest"`
-	CurrentMemLimit       int64     `json:"currentMemLimit"`
-	RecommendedCpuRequest int64     `json:"recommendedCpuRequest"`
-	RecommendedCpuLimit   int64     `json:"recommendedCpuLimit"`
-	RecommendedMemRequest int64     `json:"recommendedMemRequest"`
-	RecommendedMemLimit   int64     `json:"recommendedMemLimit"`
-	RunningHours          int64     `json:"runningHours"`
-	ControllerType        string    `json:"controllerType"`
-	Namespace             string    `json:"namespace"`
+	// Container values; CPU is in millicores and memory is in MiB
+	Container             string      `json:"container"`
+	Cluster               string      `json:"cluster"`
+	HostName              string      `json:"hostName"`
+	EstimatedSavings      Money       `json:"estimatedSavings"`
+	TotalNetSavings       Money       `json:"totalNetSavings"`
+	DisplayName           string      `json:"displayName"`
+	PodService            string      `json:"podService"`
+	CurrentCount          int64       `json:"currentCount"`
+	CurrentCpuRequest     CpuQuantity `json:"currentCpuRequest"`
+	CurrentCpuLimit       CpuQuantity `json:"currentCpuLimit"`
+	CurrentMemRequest     MemQuantity `json:"currentMemRequest"`
+	CurrentMemLimit       MemQuantity `json:"currentMemLimit"`
+	RecommendedCpuRequest CpuQuantity `json:"recommendedCpuRequest"`
+	RecommendedCpuLimit   CpuQuantity `json:"recommendedCpuLimit"`
+	RecommendedMemRequest MemQuantity `json:"recommendedMemRequest"`
+	RecommendedMemLimit   MemQuantity `json:"recommendedMemLimit"`
+	RunningHours          int64       `json:"runningHours"`
+	ControllerType        string      `json:"controllerType"`
+	Namespace             string      `json:"namespace"`
 
 	Containers []DensifyContainerRecommendation `json:"containers"`
 	Guardrails DensifyGuardrails                `json:"Guardrails"`
+	Maturity   *MaturityAssessment              `json:"maturity,omitempty"` // set when the query has a maturity policy
+
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
 type AuditInfo struct {
 	DataCollection AuditInfoDataCollection     `json:"dataCollection"`
 	WorkloadData   AuditInfoWorkloadDataLast30 `json:"workloadDataLast30"`
+
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
 type AuditInfoDataCollection struct {
-	DateFirstAudited int64 `json:"dateFirstAudited"`
-	DateLastAudited  int64 `json:"dateLastAudited"`
-	AuditCount       int64 `json:"auditCount"`
+	DateFirstAudited EpochMillis `json:"dateFirstAudited"`
+	DateLastAudited  EpochMillis `json:"dateLastAudited"`
+	AuditCount       int64       `json:"auditCount"`
+
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
 type AuditInfoWorkloadDataLast30 struct {
-	FirstDate int64 `json:"firstDate"`
-	LastDate  int64 `json:"lastDate"`
-	TotalDays int64 `json:"totalDays"`
-	SeenDays  int64 `json:"seenDays"`
+	FirstDate EpochMillis `json:"firstDate"`
+	LastDate  EpochMillis `json:"lastDate"`
+	TotalDays int64       `json:"totalDays"`
+	SeenDays  int64       `json:"seenDays"`
+
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
+// a single container's recommendation; CPU is in millicores and memory is in MiB
 type DensifyContainerRecommendation struct {
-	Container             string    `json:"container"`
-	Cluster               string    `json:"cluster"`
-	EntityId              string    `json:"entityId"`
-	EstimatedSavings      FloatType `json:"estimatedSavings"`
-	TotalNetSavings       FloatType `json:"totalNetSavings"`
-	DisplayName           string    `json:"displayName"`
-	PodService            string    `json:"podService"`
-	CurrentCount          int64     `json:"currentCount"`
-	CurrentCpuRequest     int64     `json:"currentCpuRequest"`
-	CurrentCpuLimit       int64     `json:"currentCpuLimit"`
-	CurrentMemRequest     int64     `json:"currentMemRequest"`
-	CurrentMemLimit       int64     `json:"currentMemLimit"`
-	RecommendedCpuRequest int64     `json:"recommendedCpuRequest"`
-	RecommendedCpuLimit   int64     `json:"recommendedCpuLimit"`
-	RecommendedMemRequest int64     `json:"recommendedMemRequest"`
-	RecommendedMemLimit   int64     `json:"recommendedMemLimit"`
-	FallbackCpuRequest    string    `json:"fallbackCpuRequest"`
-	FallbackCpuLimit      string    `json:"fallbackCpuLimit"`
-	FallbackMemRequest    string    `json:"fallbackMemRequest"`
-	FallbackMemLimit      string    `json:"fallbackMemLimit"`
-	RunningHours          int64     `json:"runningHours"`
-	ControllerType        string    `json:"controllerType"`
-	Namespace             string    `json:"namespace"`
-	RecommendationType    string    `json:"recommendationType"`
-	ApprovalType          string    `json:"approvalType"`
-	ApprovedType          string    `json:"approvedType"`
-	DaysRecoUnchanged     int64     `json:"recommSeenCount"`
+	Container             string              `json:"container"`
+	Cluster               string              `json:"cluster"`
+	EntityId              string              `json:"entityId"`
+	EstimatedSavings      Money               `json:"estimatedSavings"`
+	TotalNetSavings       Money               `json:"totalNetSavings"`
+	DisplayName           string              `json:"displayName"`
+	PodService            string              `json:"podService"`
+	CurrentCount          int64               `json:"currentCount"`
+	CurrentCpuRequest     CpuQuantity         `json:"currentCpuRequest"`
+	CurrentCpuLimit       CpuQuantity         `json:"currentCpuLimit"`
+	CurrentMemRequest     MemQuantity         `json:"currentMemRequest"`
+	CurrentMemLimit       MemQuantity         `json:"currentMemLimit"`
+	RecommendedCpuRequest CpuQuantity         `json:"recommendedCpuRequest"`
+	RecommendedCpuLimit   CpuQuantity         `json:"recommendedCpuLimit"`
+	RecommendedMemRequest MemQuantity         `json:"recommendedMemRequest"`
+	RecommendedMemLimit   MemQuantity         `json:"recommendedMemLimit"`
+	FallbackCpuRequest    CpuQuantity         `json:"fallbackCpuRequest"`
+	FallbackCpuLimit      CpuQuantity         `json:"fallbackCpuLimit"`
+	FallbackMemRequest    MemQuantity         `json:"fallbackMemRequest"`
+	FallbackMemLimit      MemQuantity         `json:"fallbackMemLimit"`
+	RunningHours          int64               `json:"runningHours"`
+	ControllerType        string              `json:"controllerType"`
+	Namespace             string              `json:"namespace"`
+	RecommendationType    RecommendationType  `json:"recommendationType"`
+	ApprovalType          ApprovalType        `json:"approvalType"`
+	ApprovedType          string              `json:"approvedType"`
+	DaysRecoUnchanged     int64               `json:"recommSeenCount"`
+	Maturity              *MaturityAssessment `json:"maturity,omitempty"` // set when the query has a maturity policy
+
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
 type DensifyGuardrails struct {
@@ -153,55 +168,63 @@ type DensifyGuardrails struct {
 
 	Status  int    `json:"status"`  // if there's an error, this will be populated
 	Message string `json:"message"` // if there's an error, this will be populated
+
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
 type DensifyGuardrailsCurrent struct {
-	EntityId           string    `json:"entityId"`
-	DisplayName        string    `json:"displayName"`
-	ResourceId         string    `json:"resourceId"`
-	InstanceType       string    `json:"instanceType"`
-	ResourceGroup      string    `json:"resourceGroup"`
-	BlendedScore       int       `json:"blendedScore"`
-	Compatibility      string    `json:"compatibility"`
-	CpuModel           string    `json:"cpuModel"`
-	NumCPUs            int64     `json:"numCpus"`
-	Memory             FloatType `json:"memory"`
-	Generation         int       `json:"generation"`
-	CatalogCost        Currency  `json:"catalogCost"`
-	Uptime             FloatType `json:"predictedUptime"`
-	PercentOptimalCost FloatType `json:"percentOptimalCost"`
+	EntityId           string        `json:"entityId"`
+	DisplayName        string        `json:"displayName"`
+	ResourceId         string        `json:"resourceId"`
+	InstanceType       string        `json:"instanceType"`
+	ResourceGroup      string        `json:"resourceGroup"`
+	BlendedScore       int           `json:"blendedScore"`
+	Compatibility      Compatibility `json:"compatibility"`
+	CpuModel           string        `json:"cpuModel"`
+	NumCPUs            int64         `json:"numCpus"`
+	Memory             FloatType     `json:"memory"`
+	Generation         int           `json:"generation"`
+	CatalogCost        Money         `json:"catalogCost"` // hourly
+	Uptime             FloatType     `json:"predictedUptime"`
+	PercentOptimalCost FloatType     `json:"percentOptimalCost"`
+
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
 type DensifyGuardrailsOptimal struct {
-	InstanceType       string    `json:"instanceType"`
-	BlendedScore       int       `json:"blendedScore"`
-	Compatibility      string    `json:"compatibility"`
-	RecommendationType string    `json:"recommendationType"`
-	CpuModel           string    `json:"cpuModel"`
-	NumCPUs            int64     `json:"numCpus"`
-	Memory             FloatType `json:"memory"`
-	Generation         int       `json:"generation"`
-	CatalogCost        Currency  `json:"catalogCost"`
-	EffortEstimate     string    `json:"effortEstimate"`
+	InstanceType       string             `json:"instanceType"`
+	BlendedScore       int                `json:"blendedScore"`
+	Compatibility      Compatibility      `json:"compatibility"`
+	RecommendationType RecommendationType `json:"recommendationType"`
+	CpuModel           string             `json:"cpuModel"`
+	NumCPUs            int64              `json:"numCpus"`
+	Memory             FloatType          `json:"memory"`
+	Generation         int                `json:"generation"`
+	CatalogCost        Money              `json:"catalogCost"` // hourly
+	EffortEstimate     EffortEstimate     `json:"effortEstimate"`
+
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
 type DensifyGuardrailsTarget struct {
-	InstanceType  string `json:"instanceType"`
-	BlendedScore  int    `json:"blendedScore"`
-	Compatibility string `json:"compatibility"`
-	// IncompatibilityReason []string `json:"incompatibilityReason"`
-	IncompatibilityReason string `json:"incompatibilityReason"`
+	InstanceType          string        `json:"instanceType"`
+	BlendedScore          int           `json:"blendedScore"`
+	Compatibility         Compatibility `json:"compatibility"`
+	IncompatibilityReason StringList    `json:"incompatibilityReason"` // returned as either a string or a list
+
+	CpuModel           string         `json:"cpuModel"`
+	NumCPUs            int64          `json:"numCpus"`
+	Memory             FloatType      `json:"memory"`
+	Generation         int            `json:"generation"`
+	CatalogCost        Money          `json:"catalogCost"` // hourly
+	PercentOptimalCost FloatType      `json:"percentOptimalCost"`
+	EffortEstimate     EffortEstimate `json:"effortEstimate"`
 
-	CpuModel           string    `json:"cpuModel"`
-	NumCPUs            int64     `json:"numCpus"`
-	Memory             FloatType `json:"memory"`
-	Generation         int       `json:"generation"`
-	CatalogCost        Currency  `json:"catalogCost"`
-	PercentOptimalCost FloatType `json:"percentOptimalCost"`
-	EffortEstimate     string    `json:"effortEstimate"`
+	UnknownFields // fields returned by the Densify API that aren't declared above
 }
 
 // this checks if a change has been approved (by looking at the ApprovalType) and returns the RecommendedType, otherwise it will return the CurrentType.
+// An empty ApprovalType returns an empty string, so the query's FallbackInstance can be used instead.
 func (r *DensifyRecommendation) GetApprovedType() string {
 	// basic check(s) first
 	if r == nil {
@@ -209,18 +232,37 @@ func (r *DensifyRecommendation) GetApprovedType() string {
 	}
 
 	switch r.ApprovalType {
-	case "na":
+	case "":
+		// no approval information; the caller decides (ex. the query's FallbackInstance)
+		return ""
+	case ApprovalNotApproved:
 		// not approved; use CurrentType
 		return r.CurrentType
-	case "all":
+	case ApprovalAll:
 		// all/any recommendation is approved
 		return r.RecommendedType
-	case "any":
+	case ApprovalAny:
 		// all/any recommendation is approved
 		return r.RecommendedType
 	default:
 		// specific recommendation is approved and specified in ApprovalType
-		return r.ApprovalType
+		return string(r.ApprovalType)
+	}
+}
+
+// sets the billing period of the hourly amounts, since the Densify API returns all amounts as plain numbers
+func (r *DensifyRecommendation) setMoneyPeriods() {
+	r.CurrentHourlyRate.Period = PeriodHourly
+	r.RecommendedHourlyRate.Period = PeriodHourly
+	r.Guardrails.setMoneyPeriods()
+}
+
+// sets the billing period of the (hourly) catalog costs
+func (g *DensifyGuardrails) setMoneyPeriods() {
+	g.CurrentInstance.CatalogCost.Period = PeriodHourly
+	g.OptimalInstance.CatalogCost.Period = PeriodHourly
+	for i := 0; i < len(g.Targets); i++ {
+		g.Targets[i].CatalogCost.Period = PeriodHourly
 	}
 }
 
@@ -246,10 +288,25 @@ func (pod *DensifyRecommendation) AddContainerToPod(reco *DensifyRecommendation)
 		EntityId:              reco.EntityId,
 		RecommendationType:    reco.RecommendationType,
 		DaysRecoUnchanged:     reco.RecommSeenCount,
+		RunningHours:          reco.RunningHours,
 	}
 	pod.Containers = append(pod.Containers, c)
 }
 
+// adds the container recommendation to a pod-level (workload) recommendation; the first container populates the workload itself
+func (pod *DensifyRecommendation) addContainerToWorkload(reco *DensifyRecommendation) {
+	if pod.isEmpty() {
+		*pod = *reco
+	}
+	// also manually add the container recommendation(s) to the internal list
+	pod.AddContainerToPod(reco)
+	// if there are multiple containers within the pod, let's clear out the container name
+	if len(pod.Containers) > 1 {
+		pod.Name = pod.PodService // change the (container) name > pod name
+		pod.Container = ""        // clear the container name
+	}
+}
+
 // returns true if the object is empty/nil
 func (r DensifyRecommendation) isEmpty() bool {
 	if r.Name == "" && r.Container == "" && r.Namespace == "" {
@@ -257,3 +314,117 @@ func (r DensifyRecommendation) isEmpty() bool {
 	}
 	return false
 }
+
+// the models keep any fields the Densify API returns that aren't declared, and write them back out when encoded again
+
+func (a *DensifyAnalysis) UnmarshalJSON(data []byte) error {
+	type alias DensifyAnalysis
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(a))
+	a.Unknown = unknown
+	return err
+}
+func (a DensifyAnalysis) MarshalJSON() ([]byte, error) {
+	type alias DensifyAnalysis
+	return marshalWithUnknownFields(alias(a), a.Unknown)
+}
+
+func (r *DensifyRecommendation) UnmarshalJSON(data []byte) error {
+	type alias DensifyRecommendation
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(r))
+	r.Unknown = unknown
+	r.setMoneyPeriods()
+	return err
+}
+func (r DensifyRecommendation) MarshalJSON() ([]byte, error) {
+	type alias DensifyRecommendation
+	return marshalWithUnknownFields(alias(r), r.Unknown)
+}
+
+func (a *AuditInfo) UnmarshalJSON(data []byte) error {
+	type alias AuditInfo
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(a))
+	a.Unknown = unknown
+	return err
+}
+func (a AuditInfo) MarshalJSON() ([]byte, error) {
+	type alias AuditInfo
+	return marshalWithUnknownFields(alias(a), a.Unknown)
+}
+
+func (a *AuditInfoDataCollection) UnmarshalJSON(data []byte) error {
+	type alias AuditInfoDataCollection
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(a))
+	a.Unknown = unknown
+	return err
+}
+func (a AuditInfoDataCollection) MarshalJSON() ([]byte, error) {
+	type alias AuditInfoDataCollection
+	return marshalWithUnknownFields(alias(a), a.Unknown)
+}
+
+func (a *AuditInfoWorkloadDataLast30) UnmarshalJSON(data []byte) error {
+	type alias AuditInfoWorkloadDataLast30
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(a))
+	a.Unknown = unknown
+	return err
+}
+func (a AuditInfoWorkloadDataLast30) MarshalJSON() ([]byte, error) {
+	type alias AuditInfoWorkloadDataLast30
+	return marshalWithUnknownFields(alias(a), a.Unknown)
+}
+
+func (c *DensifyContainerRecommendation) UnmarshalJSON(data []byte) error {
+	type alias DensifyContainerRecommendation
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(c))
+	c.Unknown = unknown
+	return err
+}
+func (c DensifyContainerRecommendation) MarshalJSON() ([]byte, error) {
+	type alias DensifyContainerRecommendation
+	return marshalWithUnknownFields(alias(c), c.Unknown)
+}
+
+func (g *DensifyGuardrails) UnmarshalJSON(data []byte) error {
+	type alias DensifyGuardrails
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(g))
+	g.Unknown = unknown
+	g.setMoneyPeriods()
+	return err
+}
+func (g DensifyGuardrails) MarshalJSON() ([]byte, error) {
+	type alias DensifyGuardrails
+	return marshalWithUnknownFields(alias(g), g.Unknown)
+}
+
+func (g *DensifyGuardrailsCurrent) UnmarshalJSON(data []byte) error {
+	type alias DensifyGuardrailsCurrent
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(g))
+	g.Unknown = unknown
+	return err
+}
+func (g DensifyGuardrailsCurrent) MarshalJSON() ([]byte, error) {
+	type alias DensifyGuardrailsCurrent
+	return marshalWithUnknownFields(alias(g), g.Unknown)
+}
+
+func (g *DensifyGuardrailsOptimal) UnmarshalJSON(data []byte) error {
+	type alias DensifyGuardrailsOptimal
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(g))
+	g.Unknown = unknown
+	return err
+}
+func (g DensifyGuardrailsOptimal) MarshalJSON() ([]byte, error) {
+	type alias DensifyGuardrailsOptimal
+	return marshalWithUnknownFields(alias(g), g.Unknown)
+}
+
+func (g *DensifyGuardrailsTarget) UnmarshalJSON(data []byte) error {
+	type alias DensifyGuardrailsTarget
+	unknown, err := unmarshalWithUnknownFields(data, (*alias)(g))
+	g.Unknown = unknown
+	return err
+}
+func (g DensifyGuardrailsTarget) MarshalJSON() ([]byte, error) {
+	type alias DensifyGuardrailsTarget
+	return marshalWithUnknownFields(alias(g), g.Unknown)
+}
@@ -0,0 +1,66 @@
+package densify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetApprovedType(t *testing.T) {
+	tests := []struct {
+		approval ApprovalType
+		want     string
+	}{
+		{"", ""}, // no approval information, so the caller can fall back
+		{ApprovalNotApproved, "m5.xlarge"},
+		{ApprovalAll, "m5.large"},
+		{ApprovalAny, "m5.large"},
+		{"t3.large", "t3.large"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.approval), func(t *testing.T) {
+			r := DensifyRecommendation{CurrentType: "m5.xlarge", RecommendedType: "m5.large", ApprovalType: tt.approval}
+			if got := r.GetApprovedType(); got != tt.want {
+				t.Errorf("GetApprovedType() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	var r *DensifyRecommendation
+	if got := r.GetApprovedType(); got != "" {
+		t.Errorf("GetApprovedType() on nil = %s, want empty", got)
+	}
+}
+
+func TestGetDensifyRecommendationFallbackInstance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"name":"unapproved","currentType":"m5.xlarge","recommendedType":"m5.large"},
+			{"name":"notapproved","currentType":"m5.xlarge","recommendedType":"m5.large","approvalType":"na"},
+			{"name":"approved","currentType":"m5.xlarge","recommendedType":"m5.large","approvalType":"all"}
+		]`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"unapproved", "t3.medium"},
+		{"notapproved", "m5.xlarge"},
+		{"approved", "m5.large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DensifyClient{HTTPClient: server.Client(), BaseURL: server.URL, AnalysisIds: []string{"a1"}}
+			c.Query = &DensifyAPIQuery{AnalysisTechnology: "aws", AccountNumber: "111", SystemName: tt.name, FallbackInstance: "t3.medium"}
+			reco, err := c.GetDensifyRecommendation()
+			if err != nil {
+				t.Fatalf("GetDensifyRecommendation() error: %v", err)
+			}
+			if reco.ApprovedType != tt.want {
+				t.Errorf("ApprovedType = %s, want %s", reco.ApprovedType, tt.want)
+			}
+		})
+	}
+}
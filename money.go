@@ -0,0 +1,277 @@
+package densify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// the period a cost or savings amount covers
+type BillingPeriod int
+
+const (
+	PeriodMonthly BillingPeriod = iota // Densify reports costs and savings per month, so this is the default (zero value)
+	PeriodHourly
+	PeriodAnnual
+)
+
+const (
+	hoursPerMonth = 730  // the average number of hours in a month, as used by the cloud providers' pricing
+	hoursPerYear  = 8760 // 365 days
+
+	// the currency used when a Money value doesn't specify one; the Densify API reports costs in US dollars
+	DefaultCurrency = "USD"
+
+	// the currency of a sum of amounts in different currencies, which isn't a meaningful amount (see Money.Add)
+	MixedCurrency = "mixed"
+
+	microsPerUnit = 1000000
+)
+
+// returns the number of hours in the billing period
+func (p BillingPeriod) hours() int64 {
+	switch p {
+	case PeriodHourly:
+		return 1
+	case PeriodAnnual:
+		return hoursPerYear
+	default:
+		return hoursPerMonth
+	}
+}
+
+// returns the unit of the billing period, ex. "month"
+func (p BillingPeriod) unit() string {
+	switch p {
+	case PeriodHourly:
+		return "hour"
+	case PeriodAnnual:
+		return "year"
+	default:
+		return "month"
+	}
+}
+
+func (p BillingPeriod) String() string {
+	switch p {
+	case PeriodHourly:
+		return "hourly"
+	case PeriodAnnual:
+		return "annual"
+	default:
+		return "monthly"
+	}
+}
+
+// An amount of money for a billing period. The amount is stored in millionths of the currency unit (micros) rather than as a float, so that
+// totals across large accounts don't lose cents. The JSON form is a plain number, the same as the Densify API.
+type Money struct {
+	Micros   int64
+	Currency string // ISO 4217 currency code; empty means DefaultCurrency
+	Period   BillingPeriod
+}
+
+// rounds a rational number to the nearest whole number, with halves rounded away from zero
+func roundRat(r *big.Rat) int64 {
+	abs := new(big.Rat).Abs(r)
+	abs.Add(abs, big.NewRat(1, 2))
+	q := new(big.Int).Quo(abs.Num(), abs.Denom())
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
+
+// a decimal amount, optionally with an exponent, ex. "1234.56" or "1e3"; big.Rat also accepts fractions such as "1/3", which aren't amounts
+var moneyRegexp = regexp.MustCompile(`^[+-]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)(?:[eE][+-]?[0-9]+)?$`)
+
+// Parse a decimal amount, ex. "1234.56", into Money without going through a float.
+func ParseMoney(amount string, currency string, period BillingPeriod) (Money, error) {
+	amount = strings.TrimSpace(amount)
+	if !moneyRegexp.MatchString(amount) {
+		return Money{}, fmt.Errorf("invalid money amount '%s'", amount)
+	}
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid money amount '%s'", amount)
+	}
+	return Money{
+		Micros:   roundRat(r.Mul(r, big.NewRat(microsPerUnit, 1))),
+		Currency: currency,
+		Period:   period,
+	}, nil
+}
+
+// Create Money from a float amount, rounded to the nearest micro.
+func MoneyFromFloat(amount float64, currency string, period BillingPeriod) Money {
+	return Money{
+		Micros:   int64(math.Round(amount * microsPerUnit)),
+		Currency: currency,
+		Period:   period,
+	}
+}
+
+// returns the currency code, or DefaultCurrency if it's not set
+func (m Money) CurrencyCode() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// returns true if the amount is a sum of amounts in different currencies (see Add)
+func (m Money) IsMixedCurrency() bool {
+	return m.Currency == MixedCurrency
+}
+
+// returns true if the amount is zero
+func (m Money) IsZero() bool {
+	return m.Micros == 0
+}
+
+// returns the amount as a float, for display or charting only
+func (m Money) Float64() float64 {
+	return float64(m.Micros) / microsPerUnit
+}
+
+// Convert the amount to another billing period, ex. an hourly rate to a monthly cost, using 730 hours per month and 8760 hours per year.
+func (m Money) To(period BillingPeriod) Money {
+	if m.Period == period {
+		return m
+	}
+	r := new(big.Rat).SetInt64(m.Micros)
+	r.Mul(r, big.NewRat(period.hours(), m.Period.hours()))
+	return Money{Micros: roundRat(r), Currency: m.Currency, Period: period}
+}
+
+// returns the amount per hour
+func (m Money) Hourly() Money {
+	return m.To(PeriodHourly)
+}
+
+// returns the amount per month
+func (m Money) Monthly() Money {
+	return m.To(PeriodMonthly)
+}
+
+// returns the amount per year
+func (m Money) Annual() Money {
+	return m.To(PeriodAnnual)
+}
+
+// returns the currency of the sum of two amounts: a zero amount without a currency (ex. the zero value a total starts from) takes the
+// other amount's currency, and amounts in different currencies are MixedCurrency
+func sumCurrency(m Money, o Money) string {
+	switch {
+	case m.Currency == "" && m.Micros == 0:
+		return o.Currency
+	case o.Currency == "" && o.Micros == 0:
+		return m.Currency
+	case m.CurrencyCode() == o.CurrencyCode():
+		return m.Currency
+	default:
+		return MixedCurrency
+	}
+}
+
+// Add the amounts; the other amount is converted to this amount's billing period. Amounts aren't converted between currencies, so if
+// the currencies differ the sum's currency is MixedCurrency (see IsMixedCurrency), and so is any sum it's added to.
+func (m Money) Add(o Money) Money {
+	m.Currency = sumCurrency(m, o)
+	m.Micros += o.To(m.Period).Micros
+	return m
+}
+
+// Subtract the other amount; it is converted to this amount's billing period. As with Add, if the currencies differ the result's currency
+// is MixedCurrency.
+func (m Money) Sub(o Money) Money {
+	m.Currency = sumCurrency(m, o)
+	m.Micros -= o.To(m.Period).Micros
+	return m
+}
+
+// Round the amount to a number of decimal places (0-6), with halves rounded away from zero.
+func (m Money) Round(decimals int) Money {
+	if decimals < 0 {
+		decimals = 0
+	}
+	if decimals >= 6 {
+		return m
+	}
+	unit := int64(math.Pow10(6 - decimals))
+	m.Micros = roundRat(big.NewRat(m.Micros, unit)) * unit
+	return m
+}
+
+// Format the amount with a fixed number of decimal places (0-6), ex. "1234.56"; no thousands separators, so it's locale independent.
+func (m Money) Format(decimals int) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+	if decimals > 6 {
+		decimals = 6
+	}
+	micros := m.Round(decimals).Micros
+	sign := ""
+	if micros < 0 {
+		sign = "-"
+		micros = -micros
+	}
+	s := sign + strconv.FormatInt(micros/microsPerUnit, 10)
+	if decimals > 0 {
+		s += "." + fmt.Sprintf("%06d", micros%microsPerUnit)[:decimals]
+	}
+	return s
+}
+
+// returns the amount rounded to cents, ex. "1234.56"
+func (m Money) Amount() string {
+	return m.Format(2)
+}
+
+// formats the amount rounded to cents with its currency and period, ex. "1234.56 USD/month"
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s/%s", m.Amount(), m.CurrencyCode(), m.Period.unit())
+}
+
+// the JSON form is a plain number with up to six decimals, ex. 1234.56, same as the Densify API
+func (m Money) MarshalJSON() ([]byte, error) {
+	s := m.Format(6)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return []byte(s), nil
+}
+
+// accepts a JSON number (or a quoted number), parsed exactly; the currency and period are left as they are
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	var n json.Number
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			m.Micros = 0
+			return nil
+		}
+		n = json.Number(s)
+	} else {
+		n = json.Number(data)
+	}
+	parsed, err := ParseMoney(n.String(), m.Currency, m.Period)
+	if err != nil {
+		return err
+	}
+	m.Micros = parsed.Micros
+	return nil
+}
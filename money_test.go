@@ -0,0 +1,180 @@
+package densify
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in     string
+		micros int64
+		amount string
+		hasErr bool
+	}{
+		{"1234.56", 1234560000, "1234.56", false},
+		{" 0.1 ", 100000, "0.10", false},
+		{"-20", -20000000, "-20.00", false},
+		{"0.0000005", 1, "0.00", false}, // halves round away from zero
+		{"-0.0000005", -1, "0.00", false},
+		{"1e3", 1000000000, "1000.00", false},
+		{"0.005", 5000, "0.01", false},
+		{"", 0, "", true},
+		{"12,50", 0, "", true},
+		{"$10", 0, "", true},
+		{"1/3", 0, "", true}, // a fraction, which big.Rat would accept
+		{"0x10", 0, "", true},
+		{"+5.", 5000000, "5.00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMoney(tt.in, "EUR", PeriodHourly)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) error: %v", tt.in, err)
+			}
+			if got.Micros != tt.micros || got.Currency != "EUR" || got.Period != PeriodHourly {
+				t.Errorf("ParseMoney(%q) = %+v, want %d EUR hourly", tt.in, got, tt.micros)
+			}
+			if got.Amount() != tt.amount {
+				t.Errorf("Amount() = %s, want %s", got.Amount(), tt.amount)
+			}
+		})
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	m := Money{Micros: 1234567891}
+	tests := []struct {
+		decimals int
+		want     string
+	}{
+		{-1, "1235"},
+		{0, "1235"},
+		{2, "1234.57"},
+		{4, "1234.5679"},
+		{6, "1234.567891"},
+		{9, "1234.567891"},
+	}
+	for _, tt := range tests {
+		if got := m.Format(tt.decimals); got != tt.want {
+			t.Errorf("Format(%d) = %s, want %s", tt.decimals, got, tt.want)
+		}
+	}
+	if got := m.String(); got != "1234.57 USD/month" {
+		t.Errorf("String() = %s, want 1234.57 USD/month", got)
+	}
+}
+
+func TestMoneyPeriods(t *testing.T) {
+	hourly, _ := ParseMoney("0.0961", "", PeriodHourly)
+	if got := hourly.Monthly(); got.Format(4) != "70.1530" || got.Period != PeriodMonthly {
+		t.Errorf("Monthly() = %s, want 70.1530 monthly", got.Format(4))
+	}
+	if got := hourly.Annual().Format(4); got != "841.8360" {
+		t.Errorf("Annual() = %s, want 841.8360", got)
+	}
+	monthly, _ := ParseMoney("730", "", PeriodMonthly)
+	if got := monthly.Hourly().Amount(); got != "1.00" {
+		t.Errorf("Hourly() = %s, want 1.00", got)
+	}
+	// the other amount is converted to the receiver's period
+	if got := monthly.Add(hourly).Format(4); got != "800.1530" {
+		t.Errorf("Add() = %s, want 800.1530", got)
+	}
+	if got := monthly.Sub(hourly).Format(4); got != "659.8470" {
+		t.Errorf("Sub() = %s, want 659.8470", got)
+	}
+	// many small amounts don't drift the way floats do
+	total := Money{}
+	cent, _ := ParseMoney("0.01", "", PeriodMonthly)
+	for i := 0; i < 100000; i++ {
+		total = total.Add(cent)
+	}
+	if total.Amount() != "1000.00" {
+		t.Errorf("total = %s, want 1000.00", total.Amount())
+	}
+}
+
+func TestMoneyCurrencies(t *testing.T) {
+	money := func(amount string, currency string) Money {
+		m, _ := ParseMoney(amount, currency, PeriodMonthly)
+		return m
+	}
+	tests := []struct {
+		name     string
+		m, o     Money
+		currency string
+		amount   string
+	}{
+		{"same currency", money("10", "EUR"), money("5", "EUR"), "EUR", "15.00"},
+		{"default currency", money("10", ""), money("5", "USD"), "", "15.00"},
+		{"zero value takes the other currency", Money{}, money("5", "EUR"), "EUR", "5.00"},
+		{"adding a zero value", money("10", "EUR"), Money{}, "EUR", "10.00"},
+		{"different currencies", money("10", "EUR"), money("5", "USD"), MixedCurrency, "15.00"},
+		{"default and another currency", money("10", ""), money("5", "CAD"), MixedCurrency, "15.00"},
+		{"mixed stays mixed", money("10", "EUR").Add(money("5", "USD")), money("1", "EUR"), MixedCurrency, "16.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.m.Add(tt.o)
+			if got.Currency != tt.currency || got.Amount() != tt.amount {
+				t.Errorf("Add() = %s %s, want %s %s", got.Amount(), got.Currency, tt.amount, tt.currency)
+			}
+			if got.IsMixedCurrency() != (tt.currency == MixedCurrency) {
+				t.Errorf("IsMixedCurrency() = %v", got.IsMixedCurrency())
+			}
+			if diff := tt.m.Sub(tt.o); diff.Currency != tt.currency {
+				t.Errorf("Sub() currency = %s, want %s", diff.Currency, tt.currency)
+			}
+		})
+	}
+	if got := money("10", "EUR").Add(money("5", "USD")).String(); got != "15.00 mixed/month" {
+		t.Errorf("String() = %s, want 15.00 mixed/month", got)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		in     string
+		micros int64
+		out    string
+		hasErr bool
+	}{
+		{`1234.56`, 1234560000, `1234.56`, false},
+		{`"99.5"`, 99500000, `99.5`, false},
+		{`""`, 0, `0`, false},
+		{`null`, 0, `0`, false},
+		{`0.123456789`, 123457, `0.123457`, false},
+		{`"abc"`, 0, ``, true},
+		{`"1/3"`, 0, ``, true},
+		{`true`, 0, ``, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			m := Money{Currency: "CAD", Period: PeriodAnnual}
+			err := json.Unmarshal([]byte(tt.in), &m)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %+v, want an error", tt.in, m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error: %v", tt.in, err)
+			}
+			if m.Micros != tt.micros || m.Currency != "CAD" || m.Period != PeriodAnnual {
+				t.Errorf("Unmarshal(%s) = %+v, want %d CAD annual", tt.in, m, tt.micros)
+			}
+			out, err := json.Marshal(m)
+			if err != nil || string(out) != tt.out {
+				t.Errorf("Marshal() = %s, %v; want %s", out, err, tt.out)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package densify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// a generated file
+type OverlayFile struct {
+	Path    string // relative to the overlay directory, with "/" separators
+	Content []byte
+}
+
+// a generated kustomize overlay: kustomization.yaml first, then a patch file per workload
+type KustomizeOverlay struct {
+	Files []OverlayFile
+}
+
+// options for GenerateKustomizeOverlay
+type KustomizeOptions struct {
+	Resources []string // the overlay's resources, ex. the base directory; defaults to ../base
+	PatchDir  string   // the directory the patch files are written to, relative to the overlay (without "." or ".."); defaults to patches
+	Patch     *PatchOptions
+}
+
+var overlayFileNameRegexp = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// Generate a kustomize overlay with a strategic merge patch per workload that sets its containers' requests and limits (see
+// GeneratePatch), ex. for the workloads of a cluster or namespace from GetDensifyWorkloads or GroupWorkloads. Workloads with nothing to
+// change are left out. The workloads must all be in the same cluster, since an overlay applies to one cluster. Each patch file is named
+// namespace-controllerType-name; workloads whose names would be the same file are an error. The options can be nil.
+func GenerateKustomizeOverlay(workloads map[WorkloadKey]*DensifyRecommendation, opts *KustomizeOptions) (*KustomizeOverlay, error) {
+	if opts == nil {
+		opts = &KustomizeOptions{}
+	}
+	resources := opts.Resources
+	if len(resources) == 0 {
+		resources = []string{"../base"}
+	}
+	patchDir := strings.Trim(filepath.ToSlash(opts.PatchDir), "/")
+	if patchDir == "" {
+		patchDir = "patches"
+	}
+	parts := strings.Split(patchDir, "/")
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "" || parts[i] == "." || parts[i] == ".." {
+			return nil, fmt.Errorf("invalid patch directory '%s'; it must be a directory in the overlay", opts.PatchDir)
+		}
+	}
+
+	keys, recos := sortedWorkloads(workloads)
+	overlay := KustomizeOverlay{Files: []OverlayFile{{Path: "kustomization.yaml"}}}
+	patches := []interface{}{}
+	sources := map[string]WorkloadKey{} // patch file > the workload it's for, for collisions
+	for i := 0; i < len(keys); i++ {
+		if keys[i].Cluster != keys[0].Cluster {
+			return nil, fmt.Errorf("workloads are in more than one cluster (%s, %s); generate an overlay per cluster", keys[0].Cluster, keys[i].Cluster)
+		}
+		patch, err := recos[i].GeneratePatch(PatchStrategicMergeYAML, opts.Patch)
+		if errors.Is(err, ErrNoPatchChanges) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := overlayFileNameRegexp.ReplaceAllString(fmt.Sprintf("%s-%s-%s", keys[i].Namespace, keys[i].ControllerType, keys[i].Name), "_")
+		file := OverlayFile{Path: patchDir + "/" + name + ".yaml", Content: patch}
+		if previous, found := sources[file.Path]; found {
+			return nil, fmt.Errorf("'%s' and '%s' have the same patch file '%s'", previous, keys[i], file.Path)
+		}
+		sources[file.Path] = keys[i]
+		overlay.Files = append(overlay.Files, file)
+
+		// the target makes the patch explicit, rather than relying on the patch's own metadata
+		kind, _ := recos[i].podTemplateKind()
+		target := &exportObject{}
+		target.set("kind", kind.kind)
+		target.set("name", recos[i].PodService)
+		target.set("namespace", recos[i].Namespace)
+		entry := &exportObject{}
+		entry.set("path", file.Path)
+		entry.set("target", target)
+		patches = append(patches, entry)
+	}
+
+	resourceList := make([]interface{}, len(resources))
+	for i := 0; i < len(resources); i++ {
+		resourceList[i] = resources[i]
+	}
+	kustomization := &exportObject{}
+	kustomization.set("apiVersion", "kustomize.config.k8s.io/v1beta1")
+	kustomization.set("kind", "Kustomization")
+	kustomization.set("resources", resourceList)
+	kustomization.set("patches", patches)
+	doc, err := formatYAML(kustomization)
+	if err != nil {
+		return nil, err
+	}
+	overlay.Files[0].Content = []byte(doc)
+	return &overlay, nil
+}
+
+// Write the overlay's files to a directory, creating it (and the patch directory) if needed.
+func (o *KustomizeOverlay) WriteFiles(dir string) error {
+	return writeGeneratedFiles(dir, o.Files)
+}
+
+// writes generated files to a directory, creating the directories they're in
+func writeGeneratedFiles(dir string, files []OverlayFile) error {
+	for i := 0; i < len(files); i++ {
+		path := filepath.Join(dir, filepath.FromSlash(files[i].Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, files[i].Content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the default path of each container's resources in the Helm values
+const DefaultHelmValuesPath = "{{.Container}}.resources"
+
+// options for GenerateHelmValues
+type HelmValuesOptions struct {
+	// a Go template for the dot-separated path of each container's resources in the values, ex. "{{.Name}}.{{.Container}}.resources";
+	// the fields are Cluster, Namespace, ControllerType, Name (the pod/service name) and Container. Defaults to {{.Container}}.resources.
+	PathTemplate string
+	Patch        *PatchOptions
+}
+
+// Generate a Helm values fragment that sets each container's requests and limits at the options' path, ex. to pass with --values, for the
+// workloads of a cluster or namespace from GetDensifyWorkloads or GroupWorkloads. The same rules as GeneratePatch decide which containers
+// are set. Containers that map to the same path must have the same requests and limits. The options can be nil.
+func GenerateHelmValues(workloads map[WorkloadKey]*DensifyRecommendation, opts *HelmValuesOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &HelmValuesOptions{}
+	}
+	pathTemplate := opts.PathTemplate
+	if pathTemplate == "" {
+		pathTemplate = DefaultHelmValuesPath
+	}
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(pathTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Helm values path template '%s': %v", pathTemplate, err)
+	}
+	patchOpts := opts.Patch
+	if patchOpts == nil {
+		patchOpts = &PatchOptions{}
+	}
+
+	values := &exportObject{}
+	set := map[string]string{} // path > the workload that set it, for conflicts
+	keys, recos := sortedWorkloads(workloads)
+	for i := 0; i < len(keys); i++ {
+		resources := recos[i].patchResources(patchOpts)
+		for j := 0; j < len(resources); j++ {
+			var sb strings.Builder
+			err := tmpl.Execute(&sb, ContainerKey{WorkloadKey: keys[i], Container: resources[j].Container})
+			if err != nil {
+				return nil, fmt.Errorf("invalid Helm values path template '%s': %v", pathTemplate, err)
+			}
+			path := sb.String()
+			source := keys[i].ContainerKey(resources[j].Container).String()
+			if err := values.setPath(path, resources[j].resourcesObject(), source, set); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	doc, err := formatYAML(values)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(doc), nil
+}
+
+// returns the value of an attribute, or nil if there isn't one
+func (o *exportObject) get(key string) interface{} {
+	for i := 0; i < len(o.attributes); i++ {
+		if o.attributes[i].key == key {
+			return o.attributes[i].value
+		}
+	}
+	return nil
+}
+
+// sets a value at a dot-separated path, creating the objects along the way; set tracks the paths already set (and by which source), so
+// a conflicting value is an error
+func (o *exportObject) setPath(path string, value *exportObject, source string, set map[string]string) error {
+	parts := strings.Split(path, ".")
+	for i := 0; i < len(parts); i++ {
+		if strings.TrimSpace(parts[i]) == "" {
+			return fmt.Errorf("invalid Helm values path '%s' for %s; path parts can't be empty", path, source)
+		}
+	}
+	if previous, found := set[path]; found {
+		a, err := formatJSON(o.getPath(parts))
+		if err != nil {
+			return err
+		}
+		b, err := formatJSON(value)
+		if err != nil {
+			return err
+		}
+		if a != b {
+			return fmt.Errorf("Helm values path '%s' is set to different resources by %s and %s", path, previous, source)
+		}
+		return nil
+	}
+	for setPath, previous := range set {
+		if strings.HasPrefix(setPath, path+".") || strings.HasPrefix(path, setPath+".") {
+			return fmt.Errorf("Helm values path '%s' (%s) overlaps '%s' (%s)", path, source, setPath, previous)
+		}
+	}
+
+	node := o
+	for i := 0; i < len(parts)-1; i++ {
+		child, ok := node.get(parts[i]).(*exportObject)
+		if !ok {
+			child = &exportObject{}
+			node.set(parts[i], child)
+		}
+		node = child
+	}
+	node.set(parts[len(parts)-1], value)
+	set[path] = source
+	return nil
+}
+
+// returns the value at a dot-separated path
+func (o *exportObject) getPath(parts []string) interface{} {
+	var value interface{} = o
+	for i := 0; i < len(parts); i++ {
+		node, ok := value.(*exportObject)
+		if !ok {
+			return nil
+		}
+		value = node.get(parts[i])
+	}
+	return value
+}
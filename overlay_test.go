@@ -0,0 +1,168 @@
+package densify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// returns the recommendations keyed by their workload
+func overlayTestWorkloads(recos ...DensifyRecommendation) map[WorkloadKey]*DensifyRecommendation {
+	workloads := map[WorkloadKey]*DensifyRecommendation{}
+	for i := 0; i < len(recos); i++ {
+		workloads[recos[i].WorkloadKey()] = &recos[i]
+	}
+	return workloads
+}
+
+func TestGenerateKustomizeOverlay(t *testing.T) {
+	cart := patchTestRecommendation("deployment")
+	db := patchTestRecommendation("StatefulSet")
+	db.PodService = "DB"
+	unchanged := patchTestRecommendation("daemonset")
+	unchanged.PodService = "agent"
+	unchanged.Containers = unchanged.Containers[1:2] // not approved, so there's nothing to change
+
+	overlay, err := GenerateKustomizeOverlay(overlayTestWorkloads(cart, db, unchanged), &KustomizeOptions{PatchDir: "/densify/"})
+	if err != nil {
+		t.Fatalf("GenerateKustomizeOverlay() error: %v", err)
+	}
+	paths := []string{}
+	for i := 0; i < len(overlay.Files); i++ {
+		paths = append(paths, overlay.Files[i].Path)
+	}
+	wantPaths := []string{"kustomization.yaml", "densify/shop-deployment-cart.yaml", "densify/shop-statefulset-db.yaml"}
+	if strings.Join(paths, "|") != strings.Join(wantPaths, "|") {
+		t.Errorf("files = %q, want %q", paths, wantPaths)
+	}
+
+	var kustomization struct {
+		APIVersion string   `yaml:"apiVersion"`
+		Kind       string   `yaml:"kind"`
+		Resources  []string `yaml:"resources"`
+		Patches    []struct {
+			Path   string            `yaml:"path"`
+			Target map[string]string `yaml:"target"`
+		} `yaml:"patches"`
+	}
+	if err := yaml.Unmarshal(overlay.Files[0].Content, &kustomization); err != nil {
+		t.Fatalf("Unmarshal() error: %v\n%s", err, overlay.Files[0].Content)
+	}
+	if kustomization.Kind != "Kustomization" || strings.Join(kustomization.Resources, ",") != "../base" || len(kustomization.Patches) != 2 {
+		t.Fatalf("kustomization = %+v", kustomization)
+	}
+	if p := kustomization.Patches[1]; p.Path != "densify/shop-statefulset-db.yaml" || p.Target["kind"] != "StatefulSet" || p.Target["name"] != "DB" || p.Target["namespace"] != "shop" {
+		t.Errorf("patches[1] = %+v", p)
+	}
+	// each patch file is the workload's strategic merge patch
+	patch, _ := cart.GeneratePatch(PatchStrategicMergeYAML, nil)
+	if string(overlay.Files[1].Content) != string(patch) {
+		t.Errorf("patch file = %s, want %s", overlay.Files[1].Content, patch)
+	}
+
+	dir := t.TempDir()
+	if err := overlay.WriteFiles(dir); err != nil {
+		t.Fatalf("WriteFiles() error: %v", err)
+	}
+	written, err := os.ReadFile(filepath.Join(dir, "densify", "shop-deployment-cart.yaml"))
+	if err != nil || string(written) != string(patch) {
+		t.Errorf("written patch = %s, %v", written, err)
+	}
+}
+
+func TestGenerateKustomizeOverlayErrors(t *testing.T) {
+	other := patchTestRecommendation("deployment")
+	other.Cluster = "dev"
+	unknown := patchTestRecommendation("rollout")
+	unknown.PodService = "canary"
+	// both are shop-deployment-cart-deployment-web.yaml
+	nested := patchTestRecommendation("deployment")
+	nested.PodService = "cart-deployment-web"
+	collision := patchTestRecommendation("deployment")
+	collision.Namespace = "shop-deployment-cart"
+	collision.PodService = "web"
+	tests := []struct {
+		name      string
+		workloads map[WorkloadKey]*DensifyRecommendation
+		patchDir  string
+	}{
+		{"more than one cluster", overlayTestWorkloads(patchTestRecommendation("deployment"), other), ""},
+		{"unknown controller type", overlayTestWorkloads(unknown), ""},
+		{"same patch file", overlayTestWorkloads(nested, collision), ""},
+		{"parent patch directory", overlayTestWorkloads(patchTestRecommendation("deployment")), "../patches"},
+		{"nested parent patch directory", overlayTestWorkloads(patchTestRecommendation("deployment")), "patches/../../x"},
+		{"current patch directory", overlayTestWorkloads(patchTestRecommendation("deployment")), "."},
+		{"empty patch directory part", overlayTestWorkloads(patchTestRecommendation("deployment")), "a//b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateKustomizeOverlay(tt.workloads, &KustomizeOptions{PatchDir: tt.patchDir}); err == nil {
+				t.Errorf("GenerateKustomizeOverlay() didn't return an error")
+			}
+		})
+	}
+}
+
+func TestGenerateHelmValues(t *testing.T) {
+	cart := patchTestRecommendation("deployment")
+	api := patchTestRecommendation("deployment")
+	api.PodService = "api"
+	same := patchTestRecommendation("deployment")
+	same.PodService = "same"
+	same.Containers = same.Containers[:1]
+
+	tests := []struct {
+		name      string
+		workloads map[WorkloadKey]*DensifyRecommendation
+		template  string
+		want      string
+		hasErr    bool
+	}{
+		{"default path, in container order", overlayTestWorkloads(cart), "", "web:\n  resources:\n    requests:\n      cpu: \"250m\"\n    limits:\n      memory: \"512Mi\"\ninit:\n  resources:\n    requests:\n      cpu: \"100m\"\n      memory: \"128Mi\"\n", false},
+		{"by workload", overlayTestWorkloads(same), "{{.Namespace}}.{{.Name}}.{{.Container}}", "shop:\n  same:\n    web:\n      requests:\n        cpu: \"250m\"\n      limits:\n        memory: \"512Mi\"\n", false},
+		{"the same resources at the same path", overlayTestWorkloads(cart, same), "{{.Container}}", "", false},
+		{"different resources at the same path", overlayTestWorkloads(cart, api), "resources", "", true},
+		{"empty path part", overlayTestWorkloads(cart), "{{.Cluster}}..resources", "", true},
+		{"unknown field", overlayTestWorkloads(cart), "{{.Owner}}", "", true},
+		{"invalid template", overlayTestWorkloads(cart), "{{.Name", "", true},
+		{"nothing to set", map[WorkloadKey]*DensifyRecommendation{}, "", "{}\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateHelmValues(tt.workloads, &HelmValuesOptions{PathTemplate: tt.template})
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("GenerateHelmValues() = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateHelmValues() error: %v", err)
+			}
+			var parsed map[string]interface{}
+			if err := yaml.Unmarshal(got, &parsed); err != nil {
+				t.Fatalf("Unmarshal() error: %v\n%s", err, got)
+			}
+			if tt.want != "" && string(got) != tt.want {
+				t.Errorf("GenerateHelmValues() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPathOverlap(t *testing.T) {
+	values := &exportObject{}
+	set := map[string]string{}
+	if err := values.setPath("web.resources", &exportObject{}, "a", set); err != nil {
+		t.Fatalf("setPath() error: %v", err)
+	}
+	if err := values.setPath("web", &exportObject{}, "b", set); err == nil {
+		t.Errorf("setPath() with a parent path didn't return an error")
+	}
+	if err := values.setPath("web.resources.limits", &exportObject{}, "c", set); err == nil {
+		t.Errorf("setPath() with a child path didn't return an error")
+	}
+}
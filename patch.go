@@ -0,0 +1,258 @@
+package densify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the format of a kubernetes patch
+type PatchFormat string
+
+const (
+	PatchStrategicMergeYAML PatchFormat = "strategic-merge-yaml" // a strategic merge patch, ex. for kustomize patches or kubectl patch
+	PatchStrategicMergeJSON PatchFormat = "strategic-merge-json" // a strategic merge patch as JSON, ex. for kubectl patch --type strategic
+	PatchJSON6902           PatchFormat = "json6902"             // a JSON patch (RFC 6902), ex. for kubectl patch --type json
+)
+
+// returned when none of the workload's containers have requests or limits to change
+var ErrNoPatchChanges = errors.New("no container requests or limits to change")
+
+// options for GeneratePatch
+type PatchOptions struct {
+	// the container names in the order they're listed in the manifest; JSON6902 patches address containers by index, so this is needed
+	// when the manifest's order differs from the recommendation's (all of its containers, including ones that aren't patched). Each
+	// container's name is tested before it's patched, so a wrong order fails to apply rather than patching the wrong container.
+	ContainerOrder []string
+	// also patch containers whose effective requests and limits are the current ones (ex. not approved), rather than leaving them out
+	IncludeCurrent bool
+}
+
+// a kind of workload and where its pod spec is in the manifest
+type podTemplateKind struct {
+	apiVersion string
+	kind       string
+	podSpec    []string
+}
+
+var podTemplateKinds = map[string]podTemplateKind{
+	"deployment":  {"apps/v1", "Deployment", []string{"spec", "template", "spec"}},
+	"statefulset": {"apps/v1", "StatefulSet", []string{"spec", "template", "spec"}},
+	"daemonset":   {"apps/v1", "DaemonSet", []string{"spec", "template", "spec"}},
+	"replicaset":  {"apps/v1", "ReplicaSet", []string{"spec", "template", "spec"}},
+	"job":         {"batch/v1", "Job", []string{"spec", "template", "spec"}},
+	"cronjob":     {"batch/v1", "CronJob", []string{"spec", "jobTemplate", "spec", "template", "spec"}},
+	"pod":         {"v1", "Pod", []string{"spec"}},
+}
+
+// returns the kind of the recommendation's workload
+func (r *DensifyRecommendation) podTemplateKind() (podTemplateKind, error) {
+	if !r.isKubernetes() || r.Namespace == "" || r.PodService == "" {
+		return podTemplateKind{}, fmt.Errorf("recommendation '%s' isn't for a kubernetes workload; a namespace and pod name are needed to generate a patch", r.summaryName())
+	}
+	kind, found := podTemplateKinds[strings.ToLower(r.ControllerType)]
+	if !found {
+		return podTemplateKind{}, fmt.Errorf("invalid controller type '%s' for workload '%s'; must be one of: deployment, statefulset, daemonset, replicaset, job, cronjob, pod", r.ControllerType, r.WorkloadKey())
+	}
+	return kind, nil
+}
+
+// returns the effective requests and limits of the containers to patch
+func (r *DensifyRecommendation) patchResources(opts *PatchOptions) []EffectiveResources {
+	resources := []EffectiveResources{}
+	all := r.asPod().GetEffectiveResources()
+	for i := 0; i < len(all); i++ {
+		if all[i].Source == SizingCurrent && !opts.IncludeCurrent {
+			continue
+		}
+		if all[i].resourcesObject() == nil {
+			continue
+		}
+		resources = append(resources, all[i])
+	}
+	return resources
+}
+
+// returns the recommendation as a pod-level recommendation; a container-level recommendation (ex. from GetDensifyRecommendations) becomes
+// a pod with only that container
+func (r *DensifyRecommendation) asPod() *DensifyRecommendation {
+	if len(r.Containers) > 0 || r.Container == "" {
+		return r
+	}
+	pod := *r
+	pod.AddContainerToPod(r)
+	return &pod
+}
+
+// returns the names of the recommendation's containers, in order
+func (r *DensifyRecommendation) containerNames() []string {
+	pod := r.asPod()
+	names := make([]string, len(pod.Containers))
+	for i := 0; i < len(pod.Containers); i++ {
+		names[i] = pod.Containers[i].Container
+	}
+	return names
+}
+
+// returns the container's resources as requests and limits, leaving out the ones that aren't set; nil if none are
+func (e *EffectiveResources) resourcesObject() *exportObject {
+	quantities := func(cpu CpuQuantity, mem MemQuantity) *exportObject {
+		o := &exportObject{}
+		if !cpu.IsZero() {
+			o.set("cpu", cpu.String())
+		}
+		if !mem.IsZero() {
+			o.set("memory", mem.String())
+		}
+		return o
+	}
+	resources := &exportObject{}
+	if requests := quantities(e.CpuRequest, e.MemRequest); len(requests.attributes) > 0 {
+		resources.set("requests", requests)
+	}
+	if limits := quantities(e.CpuLimit, e.MemLimit); len(limits.attributes) > 0 {
+		resources.set("limits", limits)
+	}
+	if len(resources.attributes) == 0 {
+		return nil
+	}
+	return resources
+}
+
+// Generate a kubernetes patch that sets the requests and limits of the workload's containers, from their effective resources (see
+// GetEffectiveResources): the recommended values if approved, and the fallback values if there is no (mature) recommendation.
+// Containers that would keep their current values are left out, unless IncludeCurrent is set; ErrNoPatchChanges is returned if there
+// is nothing to change. Both strategic merge and JSON6902 patches only set the cpu and memory values, keeping the containers' other
+// resources. The options can be nil.
+func (r *DensifyRecommendation) GeneratePatch(format PatchFormat, opts *PatchOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &PatchOptions{}
+	}
+	kind, err := r.podTemplateKind()
+	if err != nil {
+		return nil, err
+	}
+	resources := r.patchResources(opts)
+	if len(resources) == 0 {
+		return nil, ErrNoPatchChanges
+	}
+
+	var patch string
+	switch format {
+	case PatchStrategicMergeYAML:
+		patch, err = formatYAML(r.strategicMergePatch(kind, resources))
+	case PatchStrategicMergeJSON:
+		patch, err = formatJSON(r.strategicMergePatch(kind, resources))
+	case PatchJSON6902:
+		ops, err := r.json6902Patch(kind, resources, opts)
+		if err != nil {
+			return nil, err
+		}
+		patch, err = formatJSON(ops)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid patch format '%s'; must be one of: strategic-merge-yaml, strategic-merge-json, json6902", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(patch), nil
+}
+
+// returns a strategic merge patch; containers are merged by name, so only the listed containers and resources change
+func (r *DensifyRecommendation) strategicMergePatch(kind podTemplateKind, resources []EffectiveResources) *exportObject {
+	containers := make([]interface{}, 0, len(resources))
+	for i := 0; i < len(resources); i++ {
+		c := &exportObject{}
+		c.set("name", resources[i].Container)
+		c.set("resources", resources[i].resourcesObject())
+		containers = append(containers, c)
+	}
+	// nest the containers in the pod spec, ex. spec.template.spec.containers
+	node := &exportObject{}
+	node.set("containers", containers)
+	for i := len(kind.podSpec) - 1; i > 0; i-- {
+		parent := &exportObject{}
+		parent.set(kind.podSpec[i], node)
+		node = parent
+	}
+
+	metadata := &exportObject{}
+	metadata.set("name", r.PodService)
+	metadata.set("namespace", r.Namespace)
+	patch := &exportObject{}
+	patch.set("apiVersion", kind.apiVersion)
+	patch.set("kind", kind.kind)
+	patch.set("metadata", metadata)
+	patch.set(kind.podSpec[0], node)
+	return patch
+}
+
+// Returns JSON6902 operations that test each container's name and add its requests and limits. Each value is added on its own (ex.
+// /resources/requests/cpu), so the container's other resources are kept; since an add fails if the object it's in doesn't exist, the
+// resources, requests or limits object is added whole instead when the container has no current values in it.
+func (r *DensifyRecommendation) json6902Patch(kind podTemplateKind, resources []EffectiveResources, opts *PatchOptions) ([]interface{}, error) {
+	order := opts.ContainerOrder
+	if len(order) == 0 {
+		order = r.containerNames()
+	}
+	indexes := map[string]int{}
+	for i := 0; i < len(order); i++ {
+		indexes[order[i]] = i
+	}
+	pod := r.asPod()
+	current := map[string]*DensifyContainerRecommendation{}
+	for i := 0; i < len(pod.Containers); i++ {
+		current[pod.Containers[i].Container] = &pod.Containers[i]
+	}
+
+	// patch in manifest order, so the operations read top to bottom
+	sorted := make([]EffectiveResources, len(resources))
+	copy(sorted, resources)
+	for i := 0; i < len(sorted); i++ {
+		if _, found := indexes[sorted[i].Container]; !found {
+			return nil, fmt.Errorf("container '%s' isn't in the container order: %s", sorted[i].Container, strings.Join(order, ", "))
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return indexes[sorted[i].Container] < indexes[sorted[j].Container]
+	})
+
+	ops := []interface{}{}
+	op := func(name string, path string, value interface{}) {
+		o := &exportObject{}
+		o.set("op", name)
+		o.set("path", path)
+		o.set("value", value)
+		ops = append(ops, o)
+	}
+	for i := 0; i < len(sorted); i++ {
+		path := fmt.Sprintf("/%s/containers/%d", strings.Join(kind.podSpec, "/"), indexes[sorted[i].Container])
+		op("test", path+"/name", sorted[i].Container)
+		c := current[sorted[i].Container]
+		hasRequests := !c.CurrentCpuRequest.IsZero() || !c.CurrentMemRequest.IsZero()
+		hasLimits := !c.CurrentCpuLimit.IsZero() || !c.CurrentMemLimit.IsZero()
+		object := sorted[i].resourcesObject()
+		if !hasRequests && !hasLimits {
+			op("add", path+"/resources", object)
+			continue
+		}
+		for _, parent := range []string{"requests", "limits"} {
+			values, ok := object.get(parent).(*exportObject)
+			if !ok {
+				continue
+			}
+			if (parent == "requests" && !hasRequests) || (parent == "limits" && !hasLimits) {
+				op("add", path+"/resources/"+parent, values)
+				continue
+			}
+			for j := 0; j < len(values.attributes); j++ {
+				op("add", path+"/resources/"+parent+"/"+values.attributes[j].key, values.attributes[j].value)
+			}
+		}
+	}
+	return ops, nil
+}
@@ -0,0 +1,209 @@
+package densify
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// a deployment with an approved container, an unapproved one and one without a recommendation that has fallback values
+func patchTestRecommendation(controllerType string) DensifyRecommendation {
+	return DensifyRecommendation{Cluster: "prod", Namespace: "shop", ControllerType: controllerType, PodService: "cart", Containers: []DensifyContainerRecommendation{
+		{Container: "web", ApprovalType: ApprovalAll, CurrentCpuRequest: 500, RecommendedCpuRequest: 250, RecommendedMemLimit: 512},
+		{Container: "sidecar", ApprovalType: ApprovalNotApproved, CurrentCpuRequest: 100, RecommendedCpuRequest: 50},
+		{Container: "init", FallbackCpuRequest: 100, FallbackMemRequest: 128},
+	}}
+}
+
+// a strategic merge patch, as it's decoded
+type strategicMergePatch struct {
+	APIVersion string `yaml:"apiVersion" json:"apiVersion"`
+	Kind       string `yaml:"kind" json:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name" json:"name"`
+		Namespace string `yaml:"namespace" json:"namespace"`
+	} `yaml:"metadata" json:"metadata"`
+	Spec map[string]interface{} `yaml:"spec" json:"spec"`
+}
+
+// returns the containers at the path in the patch
+func patchContainers(t *testing.T, spec map[string]interface{}, path []string) []interface{} {
+	t.Helper()
+	node := spec
+	for i := 0; i < len(path); i++ {
+		next, ok := node[path[i]].(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s not found in %v", path[i], node)
+		}
+		node = next
+	}
+	containers, ok := node["containers"].([]interface{})
+	if !ok {
+		t.Fatalf("containers not found in %v", node)
+	}
+	return containers
+}
+
+func TestGenerateStrategicMergePatch(t *testing.T) {
+	tests := []struct {
+		controllerType string
+		apiVersion     string
+		kind           string
+		path           []string // the pod spec, after spec
+	}{
+		{"deployment", "apps/v1", "Deployment", []string{"template", "spec"}},
+		{"StatefulSet", "apps/v1", "StatefulSet", []string{"template", "spec"}},
+		{"cronjob", "batch/v1", "CronJob", []string{"jobTemplate", "spec", "template", "spec"}},
+		{"pod", "v1", "Pod", nil},
+	}
+	for _, tt := range tests {
+		for _, format := range []PatchFormat{PatchStrategicMergeYAML, PatchStrategicMergeJSON} {
+			t.Run(tt.controllerType+" "+string(format), func(t *testing.T) {
+				reco := patchTestRecommendation(tt.controllerType)
+				out, err := reco.GeneratePatch(format, nil)
+				if err != nil {
+					t.Fatalf("GeneratePatch() error: %v", err)
+				}
+				var patch strategicMergePatch
+				if format == PatchStrategicMergeYAML {
+					err = yaml.Unmarshal(out, &patch)
+				} else {
+					err = json.Unmarshal(out, &patch)
+				}
+				if err != nil {
+					t.Fatalf("Unmarshal() error: %v\n%s", err, out)
+				}
+				if patch.APIVersion != tt.apiVersion || patch.Kind != tt.kind || patch.Metadata.Name != "cart" || patch.Metadata.Namespace != "shop" {
+					t.Errorf("patch header = %+v", patch)
+				}
+				// the unapproved container keeps its current values, so it isn't patched
+				containers := patchContainers(t, patch.Spec, tt.path)
+				if len(containers) != 2 {
+					t.Fatalf("got %d containers, want 2: %v", len(containers), containers)
+				}
+				web, _ := json.Marshal(containers[0])
+				if string(web) != `{"name":"web","resources":{"limits":{"memory":"512Mi"},"requests":{"cpu":"250m"}}}` {
+					t.Errorf("web = %s", web)
+				}
+				init, _ := json.Marshal(containers[1])
+				if string(init) != `{"name":"init","resources":{"requests":{"cpu":"100m","memory":"128Mi"}}}` {
+					t.Errorf("init = %s", init)
+				}
+			})
+		}
+	}
+}
+
+func TestGenerateJSON6902Patch(t *testing.T) {
+	type operation struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+	// web has a current CPU request but no limits, sidecar only has a current CPU request and init has no current values
+	web := func(index string) []string {
+		path := "/spec/template/spec/containers/" + index
+		return []string{"test " + path + "/name", "add " + path + "/resources/requests/cpu", "add " + path + "/resources/limits"}
+	}
+	sidecar := []string{"test /spec/template/spec/containers/1/name", "add /spec/template/spec/containers/1/resources/requests/cpu"}
+	init := func(index string) []string {
+		path := "/spec/template/spec/containers/" + index
+		return []string{"test " + path + "/name", "add " + path + "/resources"}
+	}
+	tests := []struct {
+		name string
+		opts *PatchOptions
+		ops  [][]string
+	}{
+		{"recommendation order", nil, [][]string{web("0"), init("2")}},
+		{"manifest order", &PatchOptions{ContainerOrder: []string{"init", "sidecar", "web"}}, [][]string{init("0"), web("2")}},
+		{"include current", &PatchOptions{IncludeCurrent: true}, [][]string{web("0"), sidecar, init("2")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reco := patchTestRecommendation("deployment")
+			out, err := reco.GeneratePatch(PatchJSON6902, tt.opts)
+			if err != nil {
+				t.Fatalf("GeneratePatch() error: %v", err)
+			}
+			var ops []operation
+			if err := json.Unmarshal(out, &ops); err != nil {
+				t.Fatalf("Unmarshal() error: %v\n%s", err, out)
+			}
+			want := []string{}
+			for i := 0; i < len(tt.ops); i++ {
+				want = append(want, tt.ops[i]...)
+			}
+			got := []string{}
+			for i := 0; i < len(ops); i++ {
+				got = append(got, ops[i].Op+" "+ops[i].Path)
+			}
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("operations =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+
+	// the test operation checks the container name at the index before it's patched, and parent objects are added whole
+	reco := patchTestRecommendation("deployment")
+	out, _ := reco.GeneratePatch(PatchJSON6902, &PatchOptions{ContainerOrder: []string{"init", "sidecar", "web"}})
+	var ops []operation
+	_ = json.Unmarshal(out, &ops)
+	if len(ops) != 5 || ops[0].Value != "init" || ops[2].Value != "web" {
+		t.Fatalf("operations = %+v; want init, then web", ops)
+	}
+	values := []string{}
+	for _, i := range []int{1, 3, 4} {
+		value, _ := json.Marshal(ops[i].Value)
+		values = append(values, string(value))
+	}
+	want := `{"requests":{"cpu":"100m","memory":"128Mi"}}|"250m"|{"memory":"512Mi"}`
+	if strings.Join(values, "|") != want {
+		t.Errorf("add values = %s, want %s", strings.Join(values, "|"), want)
+	}
+}
+
+func TestGeneratePatchErrors(t *testing.T) {
+	unapproved := patchTestRecommendation("deployment")
+	unapproved.Containers = unapproved.Containers[1:2]
+	unknown := patchTestRecommendation("rollout")
+	containerLevel := DensifyRecommendation{Namespace: "shop", ControllerType: "deployment", PodService: "cart", Container: "web", ApprovalType: ApprovalAll, RecommendedCpuRequest: 250}
+
+	tests := []struct {
+		name   string
+		reco   DensifyRecommendation
+		format PatchFormat
+		opts   *PatchOptions
+		err    error
+	}{
+		{"nothing to change", unapproved, PatchStrategicMergeYAML, nil, ErrNoPatchChanges},
+		{"cloud recommendation", DensifyRecommendation{Name: "web"}, PatchStrategicMergeYAML, nil, nil},
+		{"unknown controller type", unknown, PatchStrategicMergeYAML, nil, nil},
+		{"invalid format", patchTestRecommendation("deployment"), "merge", nil, nil},
+		{"container not in the order", patchTestRecommendation("deployment"), PatchJSON6902, &PatchOptions{ContainerOrder: []string{"web"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.reco.GeneratePatch(tt.format, tt.opts)
+			if err == nil {
+				t.Fatalf("GeneratePatch() = %s, want an error", out)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("GeneratePatch() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	// a container-level recommendation is patched as a pod with one container
+	out, err := containerLevel.GeneratePatch(PatchJSON6902, nil)
+	if err != nil {
+		t.Fatalf("GeneratePatch() error: %v", err)
+	}
+	var ops []map[string]interface{}
+	if err := json.Unmarshal(out, &ops); err != nil || len(ops) != 2 || ops[0]["value"] != "web" {
+		t.Errorf("GeneratePatch() = %s, %v; want a patch for web", out, err)
+	}
+}
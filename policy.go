@@ -0,0 +1,356 @@
+package densify
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// options for GenerateOPAData and GenerateOPABundle
+type OPAOptions struct {
+	Root     string            // the bundle root, and so the data path, ex. densify for data.densify; defaults to densify
+	Revision string            // the bundle revision, ex. when the recommendations were loaded; optional
+	KeyBy    ExportKeyStrategy // the key of each cloud instance; defaults to the system name (see ExportKeyStrategy)
+}
+
+// options for GenerateKyvernoConfigMap
+type KyvernoOptions struct {
+	Name      string            // the ConfigMap name; defaults to densify-guardrails
+	Namespace string            // the ConfigMap namespace; defaults to kyverno
+	KeyBy     ExportKeyStrategy // the key of each cloud instance; defaults to the system name (see ExportKeyStrategy)
+}
+
+// the maximum size of a ConfigMap's data
+const configMapMaxBytes = 1 << 20
+
+// the maximum length of a ConfigMap key
+const configMapKeyMaxLength = 253
+
+// the policy data of a cloud instance, by key
+type policyInstance struct {
+	key    string
+	object *exportObject
+}
+
+// the policy data of a container
+type policyContainer struct {
+	key    ContainerKey
+	object *exportObject
+}
+
+// the policy data of the recommendations, sorted by key
+type policyData struct {
+	instances  []policyInstance
+	containers []policyContainer
+}
+
+// returns the instance types an instance is allowed to be: the guardrails targets that are OK, by descending blended score, or the
+// recommended (or current) type if there are no guardrails targets or none of them are OK, so the list is only empty if there are no types
+func (r *DensifyRecommendation) allowedInstanceTypes() []interface{} {
+	targets := []*DensifyGuardrailsTarget{}
+	for i := 0; i < len(r.Guardrails.Targets); i++ {
+		if strings.EqualFold(string(r.Guardrails.Targets[i].Compatibility), string(CompatibilityOK)) {
+			targets = append(targets, &r.Guardrails.Targets[i])
+		}
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].BlendedScore > targets[j].BlendedScore
+	})
+	allowed := []interface{}{}
+	for i := 0; i < len(targets); i++ {
+		allowed = append(allowed, targets[i].InstanceType)
+	}
+	if len(allowed) == 0 {
+		if r.RecommendedType != "" {
+			allowed = append(allowed, r.RecommendedType)
+		} else if r.CurrentType != "" {
+			allowed = append(allowed, r.CurrentType)
+		}
+	}
+	return allowed
+}
+
+// returns the policy data of a cloud instance: its allowed instance types and the compatibility of each guardrails target
+func (r *DensifyRecommendation) policyInstanceObject() *exportObject {
+	o := &exportObject{}
+	o.set("entityId", r.EntityId)
+	o.set("name", r.Name)
+	o.set("accountId", r.AccountId)
+	o.set("currentType", r.CurrentType)
+	o.set("recommendedType", r.RecommendedType)
+	o.set("allowedInstanceTypes", r.allowedInstanceTypes())
+	compatibility := &exportObject{}
+	for i := 0; i < len(r.Guardrails.Targets); i++ {
+		compatibility.set(r.Guardrails.Targets[i].InstanceType, string(r.Guardrails.Targets[i].Compatibility))
+	}
+	o.set("compatibility", compatibility)
+	return o
+}
+
+// returns the policy data of a container: its effective requests and limits, and where they come from; nil if none are set
+func (e *EffectiveResources) policyObject() *exportObject {
+	resources := e.resourcesObject()
+	if resources == nil {
+		return nil
+	}
+	o := &exportObject{}
+	o.set("source", string(e.Source))
+	o.set("reason", string(e.Reason))
+	o.attributes = append(o.attributes, resources.attributes...)
+	return o
+}
+
+// builds the policy data: cloud recommendations are instances, and the containers of kubernetes recommendations (container- or
+// pod-level) are containers with their effective resources (see GetEffectiveResources)
+func buildPolicyData(recos *[]DensifyRecommendation, keyBy ExportKeyStrategy) (*policyData, error) {
+	data := &policyData{}
+	instanceKeys := map[string]bool{}
+	containerKeys := map[ContainerKey]bool{}
+	for i := 0; recos != nil && i < len(*recos); i++ {
+		reco := &(*recos)[i]
+		if !reco.isKubernetes() {
+			key, err := keyBy.key(reco)
+			if err != nil {
+				return nil, err
+			}
+			if instanceKeys[key] {
+				return nil, fmt.Errorf("more than one instance has the key '%s'; use a different key strategy, ex. entityId", key)
+			}
+			instanceKeys[key] = true
+			data.instances = append(data.instances, policyInstance{key: key, object: reco.policyInstanceObject()})
+			continue
+		}
+		resources := reco.asPod().GetEffectiveResources()
+		for j := 0; j < len(resources); j++ {
+			object := resources[j].policyObject()
+			if object == nil {
+				continue
+			}
+			key := reco.WorkloadKey().ContainerKey(resources[j].Container)
+			if containerKeys[key] {
+				return nil, fmt.Errorf("container '%s' is in more than one recommendation", key)
+			}
+			containerKeys[key] = true
+			data.containers = append(data.containers, policyContainer{key: key, object: object})
+		}
+	}
+
+	sort.SliceStable(data.instances, func(i, j int) bool {
+		return data.instances[i].key < data.instances[j].key
+	})
+	sort.SliceStable(data.containers, func(i, j int) bool {
+		return data.containers[i].key.String() < data.containers[j].key.String()
+	})
+	return data, nil
+}
+
+// returns the object at a path of keys, creating the objects along the way
+func (o *exportObject) child(parts ...string) *exportObject {
+	node := o
+	for i := 0; i < len(parts); i++ {
+		next, ok := node.get(parts[i]).(*exportObject)
+		if !ok {
+			next = &exportObject{}
+			node.set(parts[i], next)
+		}
+		node = next
+	}
+	return node
+}
+
+// Generate an OPA data document from the recommendations, to reject instance types and container resources that Densify doesn't
+// consider OK. Cloud instances are under "instances", by the options' key, with their allowedInstanceTypes (the guardrails targets
+// that are OK, or the recommended type if none are) and the compatibility of each target. Containers are under
+// "workloads", by cluster, namespace, controller type, name and then "containers" and the container name (see WorkloadKey), with their
+// effective requests and limits (see GetEffectiveResources), ex. data.densify.workloads.prod.shop.deployment.api.containers.app.requests.cpu.
+// The options can be nil.
+func GenerateOPAData(recos *[]DensifyRecommendation, opts *OPAOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &OPAOptions{}
+	}
+	data, err := buildPolicyData(recos, opts.KeyBy)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := &exportObject{}
+	for i := 0; i < len(data.instances); i++ {
+		instances.set(data.instances[i].key, data.instances[i].object)
+	}
+	workloads := &exportObject{}
+	for i := 0; i < len(data.containers); i++ {
+		k := data.containers[i].key
+		workloads.child(k.Cluster, k.Namespace, k.ControllerType, k.Name, "containers").set(k.Container, data.containers[i].object)
+	}
+	doc := &exportObject{}
+	doc.set("instances", instances)
+	doc.set("workloads", workloads)
+
+	out, err := formatJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// a generated OPA bundle: .manifest, then the data document
+type OPABundle struct {
+	Files []OverlayFile
+}
+
+// Generate an OPA bundle with the recommendations' data document (see GenerateOPAData) under the options' root, ex. densify/data.json,
+// and a .manifest that claims the root. The options can be nil.
+func GenerateOPABundle(recos *[]DensifyRecommendation, opts *OPAOptions) (*OPABundle, error) {
+	if opts == nil {
+		opts = &OPAOptions{}
+	}
+	root := strings.Trim(opts.Root, "/")
+	if root == "" {
+		root = "densify"
+	}
+	parts := strings.Split(root, "/")
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "" || parts[i] == "." || parts[i] == ".." {
+			return nil, fmt.Errorf("invalid OPA bundle root '%s'", opts.Root)
+		}
+	}
+	data, err := GenerateOPAData(recos, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &exportObject{}
+	if opts.Revision != "" {
+		manifest.set("revision", opts.Revision)
+	}
+	manifest.set("roots", []interface{}{root})
+	manifestJSON, err := formatJSON(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return &OPABundle{Files: []OverlayFile{
+		{Path: ".manifest", Content: []byte(manifestJSON)},
+		{Path: root + "/data.json", Content: data},
+	}}, nil
+}
+
+// Write the bundle's files to a directory, ex. to serve with opa run --bundle, creating it if needed.
+func (b *OPABundle) WriteFiles(dir string) error {
+	return writeGeneratedFiles(dir, b.Files)
+}
+
+// Write the bundle as a gzipped tarball, ex. bundle.tar.gz for a bundle server.
+func (b *OPABundle) WriteTarball(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for i := 0; i < len(b.Files); i++ {
+		header := &tar.Header{Name: "/" + b.Files[i].Path, Mode: 0644, Size: int64(len(b.Files[i].Content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(b.Files[i].Content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+var configMapKeyRegexp = regexp.MustCompile(`[^-._a-zA-Z0-9]+`)
+
+// returns a value as compact JSON, ex. for a ConfigMap value
+func compactJSON(value interface{}) (string, error) {
+	indented, err := formatJSON(value)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(indented)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Generate a ConfigMap for Kyverno policies (as a context variable) from the recommendations, to reject instance types and container
+// resources that Densify doesn't consider OK. Each value is the same JSON object as in the OPA data document (see GenerateOPAData):
+// cloud instances are "instance.<key>", with characters that aren't allowed in ConfigMap keys replaced by "_", and containers are
+// "workload.<namespace>.<controllerType>.<name>.<container>", ex. workload.shop.deployment.api.app. Since a ConfigMap is applied to one
+// cluster, the containers must all be in the same cluster. Keys longer than 253 characters are an error. The options can be nil.
+func GenerateKyvernoConfigMap(recos *[]DensifyRecommendation, opts *KyvernoOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &KyvernoOptions{}
+	}
+	name := opts.Name
+	if name == "" {
+		name = "densify-guardrails"
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "kyverno"
+	}
+	data, err := buildPolicyData(recos, opts.KeyBy)
+	if err != nil {
+		return nil, err
+	}
+
+	values := &exportObject{}
+	sources := map[string]string{} // ConfigMap key > the key it's for, for collisions
+	size := 0
+	add := func(key string, source string, object *exportObject) error {
+		if len(key) > configMapKeyMaxLength {
+			return fmt.Errorf("the ConfigMap key for '%s' is %d characters, more than the %d a ConfigMap key can have", source, len(key), configMapKeyMaxLength)
+		}
+		if previous, found := sources[key]; found {
+			return fmt.Errorf("'%s' and '%s' have the same ConfigMap key '%s'", previous, source, key)
+		}
+		sources[key] = source
+		value, err := compactJSON(object)
+		if err != nil {
+			return err
+		}
+		size += len(key) + len(value)
+		values.set(key, value)
+		return nil
+	}
+	for i := 0; i < len(data.instances); i++ {
+		key := "instance." + configMapKeyRegexp.ReplaceAllString(data.instances[i].key, "_")
+		if err := add(key, data.instances[i].key, data.instances[i].object); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < len(data.containers); i++ {
+		k := data.containers[i].key
+		if k.Cluster != data.containers[0].key.Cluster {
+			return nil, fmt.Errorf("containers are in more than one cluster (%s, %s); generate a ConfigMap per cluster", data.containers[0].key.Cluster, k.Cluster)
+		}
+		key := configMapKeyRegexp.ReplaceAllString(strings.Join([]string{"workload", k.Namespace, k.ControllerType, k.Name, k.Container}, "."), "_")
+		if err := add(key, k.String(), data.containers[i].object); err != nil {
+			return nil, err
+		}
+	}
+	if size > configMapMaxBytes {
+		return nil, fmt.Errorf("the ConfigMap data is %d bytes, more than the %d bytes a ConfigMap can hold; generate a ConfigMap per namespace or account", size, configMapMaxBytes)
+	}
+
+	metadata := &exportObject{}
+	metadata.set("name", name)
+	metadata.set("namespace", namespace)
+	configMap := &exportObject{}
+	configMap.set("apiVersion", "v1")
+	configMap.set("kind", "ConfigMap")
+	configMap.set("metadata", metadata)
+	configMap.set("data", values)
+	doc, err := formatYAML(configMap)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(doc), nil
+}
@@ -0,0 +1,293 @@
+package densify
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAllowedInstanceTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		reco DensifyRecommendation
+		want string
+	}{
+		{"OK targets by score", DensifyRecommendation{CurrentType: "m5.xlarge", RecommendedType: "m5.large", Guardrails: DensifyGuardrails{Targets: []DensifyGuardrailsTarget{
+			{InstanceType: "m5.large", BlendedScore: 70, Compatibility: CompatibilityOK},
+			{InstanceType: "t3.large", BlendedScore: 95, Compatibility: CompatibilityTechnicallyIncompatible},
+			{InstanceType: "m6i.large", BlendedScore: 90, Compatibility: CompatibilityOK},
+		}}}, "m6i.large,m5.large"},
+		{"no targets", DensifyRecommendation{CurrentType: "m5.xlarge", RecommendedType: "m5.large"}, "m5.large"},
+		{"no OK targets", DensifyRecommendation{CurrentType: "m5.xlarge", RecommendedType: "m5.large", Guardrails: DensifyGuardrails{Targets: []DensifyGuardrailsTarget{
+			{InstanceType: "t3.large", BlendedScore: 95, Compatibility: CompatibilityTechnicallyIncompatible},
+		}}}, "m5.large"},
+		{"no recommended type", DensifyRecommendation{CurrentType: "m5.xlarge"}, "m5.xlarge"},
+		{"no types", DensifyRecommendation{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed := tt.reco.allowedInstanceTypes()
+			types := []string{}
+			for i := 0; i < len(allowed); i++ {
+				types = append(types, allowed[i].(string))
+			}
+			if got := strings.Join(types, ","); got != tt.want {
+				t.Errorf("allowedInstanceTypes() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// the policy data of a cloud instance, as it's decoded
+type policyTestInstance struct {
+	EntityId             string            `json:"entityId"`
+	AllowedInstanceTypes []string          `json:"allowedInstanceTypes"`
+	Compatibility        map[string]string `json:"compatibility"`
+}
+
+// the policy data of a container, as it's decoded
+type policyTestContainer struct {
+	Source   string            `json:"source"`
+	Requests map[string]string `json:"requests"`
+	Limits   map[string]string `json:"limits"`
+}
+
+func TestGenerateOPAData(t *testing.T) {
+	recos := renderTestRecommendations()
+	recos = append(recos, patchTestRecommendation("deployment"))
+	tests := []struct {
+		name string
+		opts *OPAOptions
+		api  string
+		web  string
+	}{
+		{"by system name", nil, "api-1", "web|prod"},
+		{"by entity id", &OPAOptions{KeyBy: KeyByEntityId}, "e2", "e1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := GenerateOPAData(&recos, tt.opts)
+			if err != nil {
+				t.Fatalf("GenerateOPAData() error: %v", err)
+			}
+			var doc struct {
+				Instances map[string]policyTestInstance                                                         `json:"instances"`
+				Workloads map[string]map[string]map[string]map[string]map[string]map[string]policyTestContainer `json:"workloads"`
+			}
+			if err := json.Unmarshal(out, &doc); err != nil {
+				t.Fatalf("Unmarshal() error: %v\n%s", err, out)
+			}
+			if len(doc.Instances) != 2 {
+				t.Fatalf("instances = %v, want %s and %s", doc.Instances, tt.api, tt.web)
+			}
+			// api-1 has an OK guardrails target, and web|prod has none so its recommended type is allowed
+			api := doc.Instances[tt.api]
+			if strings.Join(api.AllowedInstanceTypes, ",") != "t3.medium" || api.Compatibility["t3.medium"] != string(CompatibilityOK) {
+				t.Errorf("api-1 = %+v", api)
+			}
+			if web := doc.Instances[tt.web]; strings.Join(web.AllowedInstanceTypes, ",") != "m5.large" {
+				t.Errorf("web|prod = %+v", web)
+			}
+
+			// app isn't approved, so it keeps its current requests
+			app := doc.Workloads["prod"]["shop"]["deployment"]["cart"]["containers"]["app"]
+			if app.Source != string(SizingCurrent) || app.Requests["cpu"] != "500m" || app.Requests["memory"] != "1Gi" {
+				t.Errorf("app = %+v", app)
+			}
+			web := doc.Workloads["prod"]["shop"]["deployment"]["cart"]["containers"]["web"]
+			if web.Source != string(SizingRecommended) || web.Requests["cpu"] != "250m" || web.Limits["memory"] != "512Mi" {
+				t.Errorf("web = %+v", web)
+			}
+		})
+	}
+}
+
+func TestGenerateOPADataErrors(t *testing.T) {
+	web := DensifyRecommendation{Name: "web", CurrentType: "m5.large"}
+	cart := patchTestRecommendation("deployment")
+	tests := []struct {
+		name  string
+		recos []DensifyRecommendation
+	}{
+		{"duplicate instance key", []DensifyRecommendation{web, web}},
+		{"duplicate container", []DensifyRecommendation{cart, cart}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out, err := GenerateOPAData(&tt.recos, nil); err == nil {
+				t.Errorf("GenerateOPAData() = %s, want an error", out)
+			}
+		})
+	}
+}
+
+func TestGenerateOPABundle(t *testing.T) {
+	recos := renderTestRecommendations()
+	tests := []struct {
+		name     string
+		opts     *OPAOptions
+		dataPath string
+		manifest string
+		hasErr   bool
+	}{
+		{"default root", nil, "densify/data.json", `{"roots":["densify"]}`, false},
+		{"nested root with a revision", &OPAOptions{Root: "/infra/densify/", Revision: "2024-05-01"}, "infra/densify/data.json", `{"revision":"2024-05-01","roots":["infra/densify"]}`, false},
+		{"parent root", &OPAOptions{Root: "../densify"}, "", "", true},
+		{"empty root part", &OPAOptions{Root: "infra//densify"}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := GenerateOPABundle(&recos, tt.opts)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("GenerateOPABundle() = %+v, want an error", bundle)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateOPABundle() error: %v", err)
+			}
+			if len(bundle.Files) != 2 || bundle.Files[0].Path != ".manifest" || bundle.Files[1].Path != tt.dataPath {
+				t.Fatalf("files = %+v", bundle.Files)
+			}
+			var manifest bytes.Buffer
+			if err := json.Compact(&manifest, bundle.Files[0].Content); err != nil || manifest.String() != tt.manifest {
+				t.Errorf(".manifest = %s, %v; want %s", bundle.Files[0].Content, err, tt.manifest)
+			}
+			data, _ := GenerateOPAData(&recos, tt.opts)
+			if string(bundle.Files[1].Content) != string(data) {
+				t.Errorf("data.json = %s, want %s", bundle.Files[1].Content, data)
+			}
+		})
+	}
+}
+
+func TestOPABundleWrite(t *testing.T) {
+	recos := renderTestRecommendations()
+	bundle, err := GenerateOPABundle(&recos, nil)
+	if err != nil {
+		t.Fatalf("GenerateOPABundle() error: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := bundle.WriteFiles(dir); err != nil {
+		t.Fatalf("WriteFiles() error: %v", err)
+	}
+	for i := 0; i < len(bundle.Files); i++ {
+		written, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(bundle.Files[i].Path)))
+		if err != nil || string(written) != string(bundle.Files[i].Content) {
+			t.Errorf("written %s = %s, %v", bundle.Files[i].Path, written, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.WriteTarball(&buf); err != nil {
+		t.Fatalf("WriteTarball() error: %v", err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	names := []string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error: %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		names = append(names, header.Name)
+		if string(content) != string(bundle.Files[len(names)-1].Content) {
+			t.Errorf("%s = %s, want %s", header.Name, content, bundle.Files[len(names)-1].Content)
+		}
+	}
+	if strings.Join(names, ",") != "/.manifest,/densify/data.json" {
+		t.Errorf("tarball files = %v", names)
+	}
+}
+
+func TestGenerateKyvernoConfigMap(t *testing.T) {
+	recos := renderTestRecommendations()
+	recos = append(recos, patchTestRecommendation("deployment"))
+	out, err := GenerateKyvernoConfigMap(&recos, &KyvernoOptions{Name: "sizing"})
+	if err != nil {
+		t.Fatalf("GenerateKyvernoConfigMap() error: %v", err)
+	}
+	var configMap struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+		Metadata   struct {
+			Name      string `yaml:"name"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"metadata"`
+		Data map[string]string `yaml:"data"`
+	}
+	if err := yaml.Unmarshal(out, &configMap); err != nil {
+		t.Fatalf("Unmarshal() error: %v\n%s", err, out)
+	}
+	if configMap.Kind != "ConfigMap" || configMap.Metadata.Name != "sizing" || configMap.Metadata.Namespace != "kyverno" {
+		t.Errorf("ConfigMap header = %+v", configMap)
+	}
+	keys := []string{"instance.api-1", "instance.web_prod", "workload.shop.deployment.cart.app", "workload.shop.deployment.cart.init",
+		"workload.shop.deployment.cart.sidecar", "workload.shop.deployment.cart.web"}
+	if len(configMap.Data) != len(keys) {
+		t.Errorf("data = %v, want the keys %v", configMap.Data, keys)
+	}
+	for i := 0; i < len(keys); i++ {
+		if _, found := configMap.Data[keys[i]]; !found {
+			t.Errorf("key %s not found in %v", keys[i], configMap.Data)
+		}
+	}
+	// each value is the object in the OPA data document
+	var web policyTestInstance
+	if err := json.Unmarshal([]byte(configMap.Data["instance.web_prod"]), &web); err != nil || web.EntityId != "e1" || strings.Join(web.AllowedInstanceTypes, ",") != "m5.large" {
+		t.Errorf("instance.web_prod = %s, %v", configMap.Data["instance.web_prod"], err)
+	}
+}
+
+func TestGenerateKyvernoConfigMapErrors(t *testing.T) {
+	other := patchTestRecommendation("deployment")
+	other.Cluster = "dev"
+	other.PodService = "api"
+	long := patchTestRecommendation("deployment")
+	long.PodService = strings.Repeat("a", 240)
+	large := []DensifyRecommendation{}
+	for i := 0; i < 5000; i++ {
+		large = append(large, DensifyRecommendation{Name: fmt.Sprintf("%s-%d", strings.Repeat("x", 100), i), CurrentType: "m5.large"})
+	}
+
+	tests := []struct {
+		name  string
+		recos []DensifyRecommendation
+		err   string
+	}{
+		{"key collision", []DensifyRecommendation{{Name: "web/1", CurrentType: "m5.large"}, {Name: "web:1", CurrentType: "m5.large"}}, "the same ConfigMap key 'instance.web_1'"},
+		{"long instance key", []DensifyRecommendation{{Name: strings.Repeat("a", 250), CurrentType: "m5.large"}}, "more than the 253"},
+		{"long container key", []DensifyRecommendation{long}, "more than the 253"},
+		{"more than one cluster", []DensifyRecommendation{patchTestRecommendation("deployment"), other}, "more than one cluster"},
+		{"too large", large, "more than the 1048576 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := GenerateKyvernoConfigMap(&tt.recos, nil)
+			if err == nil {
+				t.Fatalf("GenerateKyvernoConfigMap() = %s, want an error", out)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("GenerateKyvernoConfigMap() error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package densify
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A CPU quantity in millicores, which is the unit Densify reports container CPU requests and limits in. Zero means not set.
+// String() formats it in Kubernetes quantity notation, ex. "500m" or "2".
+type CpuQuantity int64
+
+// A memory quantity in MiB (mebibytes), which is the unit Densify reports container memory requests and limits in. Zero means not set.
+// String() formats it in Kubernetes quantity notation, ex. "512Mi" or "2Gi".
+type MemQuantity int64
+
+const bytesPerMiB = 1024 * 1024
+
+// the Kubernetes quantity suffixes and their multipliers
+var quantitySuffixes = map[string]*big.Rat{
+	"n":  big.NewRat(1, 1000000000),
+	"u":  big.NewRat(1, 1000000),
+	"m":  big.NewRat(1, 1000),
+	"":   big.NewRat(1, 1),
+	"k":  big.NewRat(1000, 1),
+	"M":  big.NewRat(1000*1000, 1),
+	"G":  big.NewRat(1000*1000*1000, 1),
+	"T":  big.NewRat(1000*1000*1000*1000, 1),
+	"P":  big.NewRat(1000*1000*1000*1000*1000, 1),
+	"E":  big.NewRat(1000*1000*1000*1000*1000*1000, 1),
+	"Ki": big.NewRat(1<<10, 1),
+	"Mi": big.NewRat(1<<20, 1),
+	"Gi": big.NewRat(1<<30, 1),
+	"Ti": big.NewRat(1<<40, 1),
+	"Pi": big.NewRat(1<<50, 1),
+	"Ei": big.NewRat(1<<60, 1),
+}
+
+// Kubernetes quantity notation: a number followed by either a decimal exponent (ex. 1e3) or a suffix (ex. m, Mi, G)
+var quantityRegexp = regexp.MustCompile(`^(\+?(?:[0-9]+\.?[0-9]*|\.[0-9]+))(?:([eE][+-]?[0-9]+)|(Ki|Mi|Gi|Ti|Pi|Ei|n|u|m|k|M|G|T|P|E))?$`)
+
+// parses Kubernetes quantity notation, ex. "500m", "1.5", "1Gi", "1e3", into its value in base units (cores or bytes)
+func parseQuantity(s string) (*big.Rat, error) {
+	match := quantityRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return nil, fmt.Errorf("invalid quantity '%s'", s)
+	}
+	value, ok := new(big.Rat).SetString(strings.TrimPrefix(match[1], "+") + match[2])
+	if !ok {
+		return nil, fmt.Errorf("invalid quantity '%s'", s)
+	}
+	return value.Mul(value, quantitySuffixes[match[3]]), nil
+}
+
+// rounds a positive value up to the nearest whole number, the same way Kubernetes rounds quantities to its precision; an error is
+// returned if the result doesn't fit in an int64
+func ceilRat(r *big.Rat) (int64, error) {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if m.Sign() > 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("value is out of range")
+	}
+	return q.Int64(), nil
+}
+
+// Parse a CPU quantity in Kubernetes notation, ex. "500m", "0.5" or "2"; values are rounded up to the nearest millicore. Empty means not set (zero).
+func ParseCpuQuantity(s string) (CpuQuantity, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	cores, err := parseQuantity(s)
+	if err != nil {
+		return 0, err
+	}
+	millicores, err := ceilRat(cores.Mul(cores, big.NewRat(1000, 1)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid CPU quantity '%s': %v", s, err)
+	}
+	return CpuQuantity(millicores), nil
+}
+
+// Parse a memory quantity in Kubernetes notation, ex. "512Mi", "1Gi" or "500M"; values are rounded up to the nearest MiB. Empty means not set (zero).
+func ParseMemQuantity(s string) (MemQuantity, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	bytes, err := parseQuantity(s)
+	if err != nil {
+		return 0, err
+	}
+	mib, err := ceilRat(bytes.Mul(bytes, big.NewRat(1, bytesPerMiB)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory quantity '%s': %v", s, err)
+	}
+	return MemQuantity(mib), nil
+}
+
+// returns the quantity in millicores
+func (q CpuQuantity) Millicores() int64 {
+	return int64(q)
+}
+
+// returns the quantity in cores, ex. 1.5
+func (q CpuQuantity) Cores() float64 {
+	return float64(q) / 1000
+}
+
+// returns true if the quantity is not set
+func (q CpuQuantity) IsZero() bool {
+	return q == 0
+}
+
+// formats the quantity in Kubernetes notation: whole cores without a suffix, otherwise millicores, ex. "2" or "1500m"
+func (q CpuQuantity) String() string {
+	if q%1000 == 0 {
+		return strconv.FormatInt(int64(q)/1000, 10)
+	}
+	return strconv.FormatInt(int64(q), 10) + "m"
+}
+
+// returns the quantity in MiB
+func (q MemQuantity) MiB() int64 {
+	return int64(q)
+}
+
+// returns the quantity in bytes
+func (q MemQuantity) Bytes() int64 {
+	return int64(q) * bytesPerMiB
+}
+
+// returns true if the quantity is not set
+func (q MemQuantity) IsZero() bool {
+	return q == 0
+}
+
+// formats the quantity in Kubernetes notation using the largest binary suffix that represents it exactly, ex. "512Mi", "2Gi" or "1Ti"
+func (q MemQuantity) String() string {
+	switch {
+	case q == 0:
+		return "0"
+	case q%(1024*1024) == 0:
+		return strconv.FormatInt(int64(q)/(1024*1024), 10) + "Ti"
+	case q%1024 == 0:
+		return strconv.FormatInt(int64(q)/1024, 10) + "Gi"
+	default:
+		return strconv.FormatInt(int64(q), 10) + "Mi"
+	}
+}
+
+// returns the smallest and largest of the quantities that are set; both are zero if none are
+func quantityBounds[Q CpuQuantity | MemQuantity](values ...Q) (Q, Q) {
+	var lower, upper Q
+	for i := 0; i < len(values); i++ {
+		if values[i] == 0 {
+			continue
+		}
+		if lower == 0 || values[i] < lower {
+			lower = values[i]
+		}
+		if values[i] > upper {
+			upper = values[i]
+		}
+	}
+	return lower, upper
+}
@@ -0,0 +1,135 @@
+package densify
+
+import "testing"
+
+func TestParseCpuQuantity(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   CpuQuantity
+		str    string
+		hasErr bool
+	}{
+		{"", 0, "0", false},
+		{"  ", 0, "0", false},
+		{"500m", 500, "500m", false},
+		{"0.5", 500, "500m", false},
+		{"+.25", 250, "250m", false},
+		{"2", 2000, "2", false},
+		{"1.5", 1500, "1500m", false},
+		{"1e3", 1000000, "1000", false},
+		{"1e3m", 0, "", true}, // an exponent and a suffix
+		{"100000u", 100, "100m", false},
+		{"1n", 1, "1m", false}, // rounded up to the nearest millicore
+		{"0.0001", 1, "1m", false},
+		{"abc", 0, "", true},
+		{"-1", 0, "", true},
+		{"1.5x", 0, "", true},
+		{"1 m", 0, "", true},
+		{"1e30", 0, "", true}, // more millicores than an int64 holds
+		{"10000P", 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseCpuQuantity(tt.in)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("ParseCpuQuantity(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCpuQuantity(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCpuQuantity(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+			if got.String() != tt.str {
+				t.Errorf("String() = %s, want %s", got.String(), tt.str)
+			}
+			// the formatted value parses back to the same quantity
+			if back, err := ParseCpuQuantity(got.String()); err != nil || back != got {
+				t.Errorf("ParseCpuQuantity(%s) = %d, %v; want %d", got.String(), back, err, got)
+			}
+		})
+	}
+}
+
+func TestParseMemQuantity(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   MemQuantity
+		str    string
+		hasErr bool
+	}{
+		{"", 0, "0", false},
+		{"512Mi", 512, "512Mi", false},
+		{"1Gi", 1024, "1Gi", false},
+		{"1.5Gi", 1536, "1536Mi", false},
+		{"2Ti", 2 * 1024 * 1024, "2Ti", false},
+		{"1048576Ki", 1024, "1Gi", false},
+		{"500M", 477, "477Mi", false}, // 476.8 MiB, rounded up
+		{"1G", 954, "954Mi", false},
+		{"134217728", 128, "128Mi", false},
+		{"1e9", 954, "954Mi", false},
+		{"1", 1, "1Mi", false},
+		{"1Mb", 0, "", true},
+		{"Gi", 0, "", true},
+		{"-512Mi", 0, "", true},
+		{"1e30", 0, "", true}, // more MiB than an int64 holds
+		{"10000000Ei", 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMemQuantity(tt.in)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("ParseMemQuantity(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMemQuantity(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMemQuantity(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+			if got.String() != tt.str {
+				t.Errorf("String() = %s, want %s", got.String(), tt.str)
+			}
+			if back, err := ParseMemQuantity(got.String()); err != nil || back != got {
+				t.Errorf("ParseMemQuantity(%s) = %d, %v; want %d", got.String(), back, err, got)
+			}
+		})
+	}
+}
+
+func TestQuantityUnits(t *testing.T) {
+	if got := CpuQuantity(1500).Cores(); got != 1.5 {
+		t.Errorf("Cores() = %v, want 1.5", got)
+	}
+	if got := MemQuantity(2).Bytes(); got != 2*1024*1024 {
+		t.Errorf("Bytes() = %v, want %v", got, 2*1024*1024)
+	}
+}
+
+func TestQuantityBounds(t *testing.T) {
+	tests := []struct {
+		values       []CpuQuantity
+		lower, upper CpuQuantity
+	}{
+		{nil, 0, 0},
+		{[]CpuQuantity{0, 0}, 0, 0},
+		{[]CpuQuantity{500}, 500, 500},
+		{[]CpuQuantity{0, 250, 1000}, 250, 1000},
+		{[]CpuQuantity{1000, 0, 250}, 250, 1000},
+	}
+	for _, tt := range tests {
+		lower, upper := quantityBounds(tt.values...)
+		if lower != tt.lower || upper != tt.upper {
+			t.Errorf("quantityBounds(%v) = %d, %d; want %d, %d", tt.values, lower, upper, tt.lower, tt.upper)
+		}
+	}
+	if lower, upper := quantityBounds[MemQuantity](2048, 512, 0); lower != 512 || upper != 2048 {
+		t.Errorf("quantityBounds() = %d, %d; want 512, 2048", lower, upper)
+	}
+}
@@ -7,23 +7,31 @@ import (
 )
 
 type DensifyAPIQuery struct {
-	AnalysisTechnology string // aws, azure, gcp, k8s
-	AccountName        string // account name to look for
-	AccountNumber      string // account number to look for
-	SystemName         string // the entity name to pull recommendations for
-	SkipErrors         bool   // skip/ignore errors
+	AnalysisTechnology string       // aws, azure, gcp, k8s
+	AccountName        string       // account name to look for
+	AccountNumber      string       // account number to look for
+	SystemName         string       // the entity name (or identifier, see SystemIdKind) to pull recommendations for
+	SystemIdKind       SystemIdKind // the kind of identifier in SystemName: name (default), entityid, resourceid or any
+	SkipErrors         bool         // skip/ignore errors
+
+	PolicyName         string // only use analyses run with this policy name (optional)
+	PolicyInstanceId   string // only use analyses run with this policy instance id (optional)
+	CompletedOnly      bool   // only use analyses that have completed
+	LatestAnalysisOnly bool   // only use the most recent analysis for each account/cluster
 
 	K8sCluster        string // the k8s cluster to look for
 	K8sNamespace      string // the k8s namespace to look for
 	K8sPodName        string // the k8s pod name to look for
 	K8sContainerName  string // the k8s container name to look for (optional)
-	K8sControllerType string // the controller type used; ex. Deployment
+	K8sControllerType string // the controller type used; ex. Deployment (optional; when empty, it is resolved from the namespace and pod name)
 
 	FallbackInstance   string // the fallback instance type in case there is no recommendation yet
-	FallbackCPURequest string // the fallback CPU Request in case there is no recommendation yet
-	FallbackMemRequest string // the fallback CPU Limit in case there is no recommendation yet
-	FallbackCPULimit   string // the fallback Memory Request in case there is no recommendation yet
-	FallbackMemLimit   string // the fallback Memory Limit in case there is no recommendation yet
+	FallbackCPURequest string // the fallback CPU Request in case there is no recommendation yet, in Kubernetes notation; ex. 500m
+	FallbackMemRequest string // the fallback Memory Request in case there is no recommendation yet, in Kubernetes notation; ex. 512Mi
+	FallbackCPULimit   string // the fallback CPU Limit in case there is no recommendation yet, in Kubernetes notation; ex. 1
+	FallbackMemLimit   string // the fallback Memory Limit in case there is no recommendation yet, in Kubernetes notation; ex. 1Gi
+
+	MaturityPolicy *MaturityPolicy // when set, GetDensifyRecommendation uses the fallback values for recommendations that aren't mature
 }
 
 func (q *DensifyAPIQuery) setValuesToLowercase() {
@@ -31,10 +39,14 @@ func (q *DensifyAPIQuery) setValuesToLowercase() {
 	q.AccountName = strings.ToLower(q.AccountName)
 	q.AccountNumber = strings.ToLower(q.AccountNumber)
 	q.SystemName = strings.ToLower(q.SystemName)
+	q.SystemIdKind = SystemIdKind(strings.ToLower(string(q.SystemIdKind)))
 	q.K8sCluster = strings.ToLower(q.K8sCluster)
 	q.K8sNamespace = strings.ToLower(q.K8sNamespace)
 	q.K8sPodName = strings.ToLower(q.K8sPodName)
+	q.K8sContainerName = strings.ToLower(q.K8sContainerName)
 	q.K8sControllerType = strings.ToLower(q.K8sControllerType)
+	q.PolicyName = strings.ToLower(q.PolicyName)
+	q.PolicyInstanceId = strings.ToLower(q.PolicyInstanceId)
 }
 
 // check if the query is for Kubernetes/containers
@@ -49,16 +61,67 @@ func (q *DensifyAPIQuery) isKubernetesRequest() bool {
 	}
 }
 
-func (q *DensifyAPIQuery) validate() error {
+// validate the query has enough information to look up the account or cluster (analysis)
+func (q *DensifyAPIQuery) validateAnalysis() error {
 	_, err := q.getURIPath()
 	if err != nil {
 		return err
 	}
+	if q.isKubernetesRequest() {
+		if q.K8sCluster == "" {
+			return fmt.Errorf("query must have k8s cluster")
+		}
+	} else {
+		if q.AccountNumber == "" && q.AccountName == "" {
+			return fmt.Errorf("query must have Account Name or Account Number")
+		}
+	}
+	// the fallback values must be valid kubernetes quantities
+	_, err = q.getFallbackResources()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// the fallback container resources from the query, parsed from Kubernetes notation
+type fallbackResources struct {
+	CpuRequest CpuQuantity
+	CpuLimit   CpuQuantity
+	MemRequest MemQuantity
+	MemLimit   MemQuantity
+}
+
+// parses the query's fallback CPU and memory values
+func (q *DensifyAPIQuery) getFallbackResources() (*fallbackResources, error) {
+	var f fallbackResources
+	var err error
+	if f.CpuRequest, err = ParseCpuQuantity(q.FallbackCPURequest); err != nil {
+		return nil, fmt.Errorf("query fallback CPU request: %v", err)
+	}
+	if f.CpuLimit, err = ParseCpuQuantity(q.FallbackCPULimit); err != nil {
+		return nil, fmt.Errorf("query fallback CPU limit: %v", err)
+	}
+	if f.MemRequest, err = ParseMemQuantity(q.FallbackMemRequest); err != nil {
+		return nil, fmt.Errorf("query fallback memory request: %v", err)
+	}
+	if f.MemLimit, err = ParseMemQuantity(q.FallbackMemLimit); err != nil {
+		return nil, fmt.Errorf("query fallback memory limit: %v", err)
+	}
+	return &f, nil
+}
+
+// validate the query has enough information to look up a single recommendation
+func (q *DensifyAPIQuery) validate() error {
+	err := q.validateAnalysis()
+	if err != nil {
+		return err
+	}
 	// validate the query parameters passed are sufficient
 	if q.isKubernetesRequest() {
 		// k8s validation
-		if q.K8sCluster == "" || q.K8sNamespace == "" || q.K8sControllerType == "" || q.K8sPodName == "" {
-			return fmt.Errorf("query must have required k8s fields: cluster, namespace, controllerType, podName, containerName")
+		if q.K8sCluster == "" || q.K8sNamespace == "" || q.K8sPodName == "" {
+			return fmt.Errorf("query must have required k8s fields: cluster, namespace, podName")
 		}
 		if !q.isValidControllerType() {
 			return fmt.Errorf("query controller type must be valid: pod, deployment, replicaset, daemonset, statefulset, cronjob, job")
@@ -68,8 +131,8 @@ func (q *DensifyAPIQuery) validate() error {
 		if q.SystemName == "" {
 			return fmt.Errorf("query must have System Name")
 		}
-		if q.AccountNumber == "" && q.AccountName == "" {
-			return fmt.Errorf("query must have Account Name or Account Number")
+		if !q.SystemIdKind.isValid() {
+			return fmt.Errorf("query system id kind must be valid: name, entityid, resourceid, any")
 		}
 	}
 	// no errors means it's a valid looking query
@@ -77,8 +140,12 @@ func (q *DensifyAPIQuery) validate() error {
 }
 
 func (q *DensifyAPIQuery) isValidControllerType() bool {
-	// check the controller types
-	switch strings.ToLower(q.K8sControllerType) {
+	return isValidControllerType(q.K8sControllerType)
+}
+
+// check the controller type is one that Densify reports on
+func isValidControllerType(controllerType string) bool {
+	switch strings.ToLower(controllerType) {
 	case "deployment":
 		return true
 	case "":
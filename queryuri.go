@@ -0,0 +1,313 @@
+package densify
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Densify query URIs are a canonical string form of a DensifyAPIQuery, for use in CLI flags, annotations and config files:
+//
+//	densify://aws/account=123456789012/system=web-01?fallback=m6i.large
+//	densify://aws/account=123456789012/system=i-0abc123def4567890?idkind=resourceid
+//	densify://azure/accountname=my-subscription/system=vm-01
+//	densify://k8s/prod-cluster/payments/deployment/api?container=app
+//	densify://k8s/prod-cluster/payments
+//
+// Cloud paths are key=value segments (account, accountname, system). Kubernetes paths are positional:
+// cluster[/namespace[/controllerType/podName]], where a controller type of "*" is resolved from the namespace and pod name, and the
+// namespace and pod name can be empty, ex. densify://k8s/prod-cluster//deployment/api. Path segments and parameter values are URL escaped.
+const QueryURIScheme = "densify"
+
+// the query string parameters, in the order they are formatted
+const (
+	uriParamContainer          = "container"
+	uriParamSystemIdKind       = "idkind"
+	uriParamFallback           = "fallback"
+	uriParamFallbackCPURequest = "fallbackcpurequest"
+	uriParamFallbackCPULimit   = "fallbackcpulimit"
+	uriParamFallbackMemRequest = "fallbackmemrequest"
+	uriParamFallbackMemLimit   = "fallbackmemlimit"
+	uriParamPolicy             = "policy"
+	uriParamPolicyInstanceId   = "policyinstanceid"
+	uriParamCompleted          = "completed"
+	uriParamLatest             = "latest"
+	uriParamSkipErrors         = "skiperrors"
+)
+
+// the cloud path keys
+const (
+	uriKeyAccount     = "account"
+	uriKeyAccountName = "accountname"
+	uriKeySystem      = "system"
+)
+
+// the kubernetes controller type path segment used when the controller type should be resolved from the namespace and pod name
+const uriAnyControllerType = "*"
+
+// returned when a query URI cannot be parsed; Component is the part of the URI that is invalid, ex. "scheme", "path segment 2", "parameter fallback"
+type QueryURIError struct {
+	URI       string
+	Component string
+	Message   string
+}
+
+func (e *QueryURIError) Error() string {
+	return fmt.Sprintf("invalid densify query uri '%s': %s: %s", e.URI, e.Component, e.Message)
+}
+
+// Parse a query URI, ex. densify://aws/account=123456789012/system=web-01, into a DensifyAPIQuery. The values are returned as written;
+// ConfigureQuery takes care of lowercasing and validating the query.
+func ParseQueryURI(uri string) (*DensifyAPIQuery, error) {
+	uriErr := func(component string, format string, a ...interface{}) error {
+		return &QueryURIError{URI: uri, Component: component, Message: fmt.Sprintf(format, a...)}
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, uriErr("uri", "%v", err)
+	}
+	if !strings.EqualFold(u.Scheme, QueryURIScheme) {
+		return nil, uriErr("scheme", "must be '%s://', found '%s'", QueryURIScheme, u.Scheme)
+	}
+	if u.User != nil || u.Port() != "" || u.Fragment != "" {
+		return nil, uriErr("uri", "user info, ports and fragments are not supported")
+	}
+
+	q := DensifyAPIQuery{AnalysisTechnology: strings.ToLower(u.Host)}
+	if _, err := q.getURIPath(); err != nil {
+		return nil, uriErr("technology", "'%s' is not one of: aws, azure, gcp, kubernetes, k8s", u.Host)
+	}
+
+	// split the escaped path so that escaped slashes stay within a segment
+	segments := []string{}
+	escapedPath := strings.TrimPrefix(u.EscapedPath(), "/")
+	if escapedPath != "" {
+		segments = strings.Split(escapedPath, "/")
+	}
+	for i := 0; i < len(segments); i++ {
+		segment, err := url.PathUnescape(segments[i])
+		if err != nil {
+			return nil, uriErr(fmt.Sprintf("path segment %d", i+1), "%v", err)
+		}
+		// kubernetes paths are positional, so their empty segments are checked by position
+		if segment == "" && !q.isKubernetesRequest() {
+			return nil, uriErr(fmt.Sprintf("path segment %d", i+1), "cannot be empty")
+		}
+		segments[i] = segment
+	}
+
+	if q.isKubernetesRequest() {
+		err = q.setK8sPathSegments(segments, uriErr)
+	} else {
+		err = q.setCloudPathSegments(segments, uriErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, uriErr("query string", "%v", err)
+	}
+	for key, values := range params {
+		component := fmt.Sprintf("parameter %s", key)
+		if len(values) > 1 {
+			return nil, uriErr(component, "specified more than once")
+		}
+		err = q.setURIParameter(strings.ToLower(key), values[0])
+		if err != nil {
+			return nil, uriErr(component, "%v", err)
+		}
+	}
+	return &q, nil
+}
+
+// sets the kubernetes positional path values: cluster[/namespace[/controllerType/podName]]
+func (q *DensifyAPIQuery) setK8sPathSegments(segments []string, uriErr func(string, string, ...interface{}) error) error {
+	switch len(segments) {
+	case 0:
+		return uriErr("path", "must have at least the cluster: cluster[/namespace[/controllerType/podName]]")
+	case 3:
+		return uriErr("path", "the pod name is missing: cluster[/namespace[/controllerType/podName]]")
+	}
+	if len(segments) > 4 {
+		return uriErr("path segment 5", "too many segments: cluster[/namespace[/controllerType/podName]]")
+	}
+	if segments[0] == "" {
+		return uriErr("path segment 1", "the cluster cannot be empty")
+	}
+	q.K8sCluster = segments[0]
+	if len(segments) > 1 {
+		q.K8sNamespace = segments[1]
+	}
+	if len(segments) > 3 {
+		if segments[2] == "" {
+			return uriErr("path segment 3", "the controller type cannot be empty; use '*' to resolve it from the namespace and pod name")
+		} else if segments[2] == uriAnyControllerType {
+			segments[2] = ""
+		} else if !isValidControllerType(segments[2]) {
+			return uriErr("path segment 3", "controller type '%s' must be '*' or one of: pod, deployment, replicaset, daemonset, statefulset, cronjob, job", segments[2])
+		}
+		q.K8sControllerType = segments[2]
+		q.K8sPodName = segments[3]
+	}
+	return nil
+}
+
+// sets the cloud key=value path values
+func (q *DensifyAPIQuery) setCloudPathSegments(segments []string, uriErr func(string, string, ...interface{}) error) error {
+	seen := map[string]bool{}
+	for i := 0; i < len(segments); i++ {
+		component := fmt.Sprintf("path segment %d", i+1)
+		key, value, found := strings.Cut(segments[i], "=")
+		key = strings.ToLower(key)
+		if !found || value == "" {
+			return uriErr(component, "'%s' must be key=value", segments[i])
+		}
+		if seen[key] {
+			return uriErr(component, "'%s' specified more than once", key)
+		}
+		seen[key] = true
+		switch key {
+		case uriKeyAccount:
+			q.AccountNumber = value
+		case uriKeyAccountName:
+			q.AccountName = value
+		case uriKeySystem:
+			q.SystemName = value
+		default:
+			return uriErr(component, "unknown key '%s'; must be one of: %s, %s, %s", key, uriKeyAccount, uriKeyAccountName, uriKeySystem)
+		}
+	}
+	if q.AccountNumber == "" && q.AccountName == "" {
+		return uriErr("path", "must have an %s or %s segment", uriKeyAccount, uriKeyAccountName)
+	}
+	return nil
+}
+
+// sets a single query string parameter
+func (q *DensifyAPIQuery) setURIParameter(key string, value string) error {
+	parseBool := func() (bool, error) {
+		// a parameter without a value, ex. ?completed, is true
+		if value == "" {
+			return true, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("'%s' is not a valid boolean", value)
+		}
+		return b, nil
+	}
+
+	var err error
+	switch key {
+	case uriParamContainer:
+		if !q.isKubernetesRequest() {
+			return fmt.Errorf("only valid for kubernetes queries")
+		}
+		q.K8sContainerName = value
+	case uriParamSystemIdKind:
+		if q.isKubernetesRequest() {
+			return fmt.Errorf("only valid for cloud queries")
+		}
+		q.SystemIdKind = SystemIdKind(strings.ToLower(value))
+		if !q.SystemIdKind.isValid() {
+			return fmt.Errorf("'%s' must be one of: name, entityid, resourceid, any", value)
+		}
+	case uriParamFallback:
+		q.FallbackInstance = value
+	case uriParamFallbackCPURequest:
+		q.FallbackCPURequest = value
+	case uriParamFallbackCPULimit:
+		q.FallbackCPULimit = value
+	case uriParamFallbackMemRequest:
+		q.FallbackMemRequest = value
+	case uriParamFallbackMemLimit:
+		q.FallbackMemLimit = value
+	case uriParamPolicy:
+		q.PolicyName = value
+	case uriParamPolicyInstanceId:
+		q.PolicyInstanceId = value
+	case uriParamCompleted:
+		q.CompletedOnly, err = parseBool()
+	case uriParamLatest:
+		q.LatestAnalysisOnly, err = parseBool()
+	case uriParamSkipErrors:
+		q.SkipErrors, err = parseBool()
+	default:
+		return fmt.Errorf("unknown parameter")
+	}
+	return err
+}
+
+// Format the query as a query URI, ex. densify://aws/account=123456789012/system=web-01; the result can be parsed back with ParseQueryURI
+// when the query has the values it requires: a cluster for kubernetes, or an account number or name for cloud.
+func (q *DensifyAPIQuery) URI() string {
+	var sb strings.Builder
+	sb.WriteString(QueryURIScheme + "://" + q.AnalysisTechnology)
+
+	if q.isKubernetesRequest() {
+		// empty segments are kept when a later segment is set (ex. a pod name without a namespace), so the positions are preserved
+		segments := []string{q.K8sCluster}
+		if q.K8sPodName != "" || q.K8sControllerType != "" {
+			controllerType := q.K8sControllerType
+			if controllerType == "" {
+				controllerType = uriAnyControllerType
+			}
+			segments = append(segments, q.K8sNamespace, controllerType, q.K8sPodName)
+		} else if q.K8sNamespace != "" {
+			segments = append(segments, q.K8sNamespace)
+		}
+		for i := 0; i < len(segments); i++ {
+			if i == 2 && segments[i] == uriAnyControllerType {
+				// written as is, as in the documented form
+				sb.WriteString("/" + uriAnyControllerType)
+				continue
+			}
+			sb.WriteString("/" + url.PathEscape(segments[i]))
+		}
+	} else {
+		if q.AccountNumber != "" {
+			sb.WriteString("/" + uriKeyAccount + "=" + url.PathEscape(q.AccountNumber))
+		}
+		if q.AccountName != "" {
+			sb.WriteString("/" + uriKeyAccountName + "=" + url.PathEscape(q.AccountName))
+		}
+		if q.SystemName != "" {
+			sb.WriteString("/" + uriKeySystem + "=" + url.PathEscape(q.SystemName))
+		}
+	}
+
+	// add the parameters in a fixed order so the output is stable
+	params := [][2]string{
+		{uriParamContainer, q.K8sContainerName},
+		{uriParamSystemIdKind, string(q.SystemIdKind)},
+		{uriParamFallback, q.FallbackInstance},
+		{uriParamFallbackCPURequest, q.FallbackCPURequest},
+		{uriParamFallbackCPULimit, q.FallbackCPULimit},
+		{uriParamFallbackMemRequest, q.FallbackMemRequest},
+		{uriParamFallbackMemLimit, q.FallbackMemLimit},
+		{uriParamPolicy, q.PolicyName},
+		{uriParamPolicyInstanceId, q.PolicyInstanceId},
+	}
+	if q.CompletedOnly {
+		params = append(params, [2]string{uriParamCompleted, "true"})
+	}
+	if q.LatestAnalysisOnly {
+		params = append(params, [2]string{uriParamLatest, "true"})
+	}
+	if q.SkipErrors {
+		params = append(params, [2]string{uriParamSkipErrors, "true"})
+	}
+	pre := "?"
+	for i := 0; i < len(params); i++ {
+		if params[i][1] == "" {
+			continue
+		}
+		sb.WriteString(pre + params[i][0] + "=" + url.QueryEscape(params[i][1]))
+		pre = "&"
+	}
+	return sb.String()
+}
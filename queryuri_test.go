@@ -0,0 +1,117 @@
+package densify
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseQueryURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want DensifyAPIQuery
+	}{
+		{"densify://aws/account=123456789012/system=web-01?fallback=m6i.large",
+			DensifyAPIQuery{AnalysisTechnology: "aws", AccountNumber: "123456789012", SystemName: "web-01", FallbackInstance: "m6i.large"}},
+		{"densify://AWS/system=i-0abc/account=1?idkind=ResourceId&completed&latest=false",
+			DensifyAPIQuery{AnalysisTechnology: "aws", AccountNumber: "1", SystemName: "i-0abc", SystemIdKind: SystemIdResourceId, CompletedOnly: true}},
+		{"densify://azure/accountname=my%2Fsubscription/system=vm%20one?policy=Prod&policyinstanceid=p1",
+			DensifyAPIQuery{AnalysisTechnology: "azure", AccountName: "my/subscription", SystemName: "vm one", PolicyName: "Prod", PolicyInstanceId: "p1"}},
+		{"densify://k8s/prod-cluster/payments/deployment/api?container=app&fallbackcpurequest=500m",
+			DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod-cluster", K8sNamespace: "payments", K8sControllerType: "deployment",
+				K8sPodName: "api", K8sContainerName: "app", FallbackCPURequest: "500m"}},
+		{"densify://k8s/prod-cluster/payments/*/api",
+			DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod-cluster", K8sNamespace: "payments", K8sPodName: "api"}},
+		{"densify://k8s/prod-cluster//deployment/api",
+			DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod-cluster", K8sControllerType: "deployment", K8sPodName: "api"}},
+		{"densify://kubernetes/prod-cluster?skiperrors",
+			DensifyAPIQuery{AnalysisTechnology: "kubernetes", K8sCluster: "prod-cluster", SkipErrors: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, err := ParseQueryURI(tt.uri)
+			if err != nil {
+				t.Fatalf("ParseQueryURI() error: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseQueryURI() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryURIErrors(t *testing.T) {
+	tests := []struct {
+		uri       string
+		component string
+	}{
+		{"https://aws/account=1", "scheme"},
+		{"densify://user@aws/account=1", "uri"},
+		{"densify://aws:8080/account=1", "uri"},
+		{"densify://oracle/account=1", "technology"},
+		{"densify://aws/system=web", "path"},
+		{"densify://aws/account", "path segment 1"},
+		{"densify://aws/account=1//system=web", "path segment 2"},
+		{"densify://aws/account=1/account=2", "path segment 2"},
+		{"densify://aws/region=us-east-1", "path segment 1"},
+		{"densify://aws/account=1?container=app", "parameter container"},
+		{"densify://aws/account=1?idkind=serial", "parameter idkind"},
+		{"densify://aws/account=1?completed=maybe", "parameter completed"},
+		{"densify://aws/account=1?fallback=a&fallback=b", "parameter fallback"},
+		{"densify://aws/account=1?unknown=1", "parameter unknown"},
+		{"densify://k8s", "path"},
+		{"densify://k8s//payments", "path segment 1"},
+		{"densify://k8s/prod/payments/deployment", "path"},
+		{"densify://k8s/prod/payments//api", "path segment 3"},
+		{"densify://k8s/prod/payments/operator/api", "path segment 3"},
+		{"densify://k8s/prod/payments/deployment/api/extra", "path segment 5"},
+		{"densify://k8s/prod?idkind=name", "parameter idkind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			_, err := ParseQueryURI(tt.uri)
+			var uriErr *QueryURIError
+			if !errors.As(err, &uriErr) {
+				t.Fatalf("ParseQueryURI() error = %v, want a QueryURIError", err)
+			}
+			if uriErr.Component != tt.component {
+				t.Errorf("error component = '%s', want '%s' (%v)", uriErr.Component, tt.component, err)
+			}
+		})
+	}
+}
+
+func TestQueryURIRoundTrip(t *testing.T) {
+	tests := []struct {
+		query DensifyAPIQuery
+		uri   string
+	}{
+		{DensifyAPIQuery{AnalysisTechnology: "aws", AccountNumber: "123", SystemName: "web 01", FallbackInstance: "m6i.large", CompletedOnly: true},
+			"densify://aws/account=123/system=web%2001?fallback=m6i.large&completed=true"},
+		{DensifyAPIQuery{AnalysisTechnology: "gcp", AccountName: "proj/a", SystemIdKind: SystemIdAny, PolicyInstanceId: "p1", LatestAnalysisOnly: true},
+			"densify://gcp/accountname=proj%2Fa?idkind=any&policyinstanceid=p1&latest=true"},
+		{DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod"}, "densify://k8s/prod"},
+		{DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod", K8sNamespace: "shop"}, "densify://k8s/prod/shop"},
+		{DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod", K8sNamespace: "shop", K8sPodName: "api", K8sContainerName: "app"},
+			"densify://k8s/prod/shop/*/api?container=app"},
+		{DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod", K8sPodName: "api", K8sControllerType: "deployment"},
+			"densify://k8s/prod//deployment/api"},
+		{DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod", K8sNamespace: "shop", K8sControllerType: "statefulset"},
+			"densify://k8s/prod/shop/statefulset/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			uri := tt.query.URI()
+			if uri != tt.uri {
+				t.Errorf("URI() = %s, want %s", uri, tt.uri)
+			}
+			parsed, err := ParseQueryURI(uri)
+			if err != nil {
+				t.Fatalf("ParseQueryURI(%s) error: %v", uri, err)
+			}
+			if !reflect.DeepEqual(*parsed, tt.query) {
+				t.Errorf("ParseQueryURI(URI()) = %+v, want %+v", *parsed, tt.query)
+			}
+		})
+	}
+}
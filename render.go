@@ -0,0 +1,261 @@
+package densify
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var bundledTemplates embed.FS
+
+// The data passed to templates:
+//
+//	.Recommendations  []DensifyRecommendation (with their Containers and Guardrails)
+//	.Summary          *RecommendationSummary, the overall totals (see SummarizeRecommendations)
+//	.GeneratedAt      time.Time
+//
+// Exported recommendation methods can be used too, ex. {{.GetApprovedType}}, {{.MonthlySavings}} or {{.WorkloadKey}}.
+type TemplateData struct {
+	Recommendations []DensifyRecommendation
+	Summary         *RecommendationSummary
+	GeneratedAt     time.Time
+}
+
+// Create the data for rendering the recommendations with a template.
+func NewTemplateData(recos *[]DensifyRecommendation) (*TemplateData, error) {
+	summary, err := SummarizeRecommendations(recos, nil)
+	if err != nil {
+		return nil, err
+	}
+	data := TemplateData{
+		Recommendations: []DensifyRecommendation{},
+		Summary:         summary,
+		GeneratedAt:     time.Now().UTC(),
+	}
+	if recos != nil {
+		data.Recommendations = *recos
+	}
+	return &data, nil
+}
+
+// recommendations grouped by a field, for the groupBy template function
+type TemplateGroup struct {
+	Key             string
+	Recommendations []DensifyRecommendation
+}
+
+var alnumRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Returns the template helper functions:
+//
+//	money, moneyf    format Money with 2 (or n) decimals: {{money .SavingsEstimate}}, {{moneyf 4 .CurrentHourlyRate}}
+//	hourly, monthly, annual  convert Money to a billing period
+//	cpu, mem         format a quantity in kubernetes notation, ex. 250m or 512Mi; millicores, cores and mib return numbers
+//	quote, squote, hclQuote  quote a string as JSON/YAML (double), YAML (single) or HCL; json encodes any value
+//	mdEscape         escape a string for a Markdown table cell
+//	alnum            remove everything but letters and digits, ex. for CloudFormation parameter names
+//	sortBy, reverse  sort recommendations by a field or method name, ex. {{range sortBy "MonthlySavings" .Recommendations | reverse}}
+//	groupBy          group recommendations by a field or method name, sorted by the group key: {{range groupBy "Region" .Recommendations}}
+//	cloud, kubernetes  only the cloud (or kubernetes) recommendations: {{range cloud .Recommendations}}
+//	lower, upper, join, indent, default
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"money":      func(m Money) string { return m.Amount() },
+		"moneyf":     func(decimals int, m Money) string { return m.Format(decimals) },
+		"hourly":     func(m Money) Money { return m.Hourly() },
+		"monthly":    func(m Money) Money { return m.Monthly() },
+		"annual":     func(m Money) Money { return m.Annual() },
+		"cpu":        func(q CpuQuantity) string { return q.String() },
+		"mem":        func(q MemQuantity) string { return q.String() },
+		"millicores": func(q CpuQuantity) int64 { return q.Millicores() },
+		"cores":      func(q CpuQuantity) float64 { return q.Cores() },
+		"mib":        func(q MemQuantity) int64 { return q.MiB() },
+		"quote":      jsonQuote,
+		"squote":     func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" },
+		"hclQuote":   hclQuote,
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"mdEscape": markdownEscape,
+		"alnum":    func(s string) string { return alnumRegexp.ReplaceAllString(s, "") },
+		"sortBy":   sortRecommendationsBy,
+		"reverse":  reverseRecommendations,
+		"groupBy":  groupRecommendationsBy,
+		"cloud": func(recos []DensifyRecommendation) []DensifyRecommendation {
+			return filterRecommendations(recos, false)
+		},
+		"kubernetes": func(recos []DensifyRecommendation) []DensifyRecommendation {
+			return filterRecommendations(recos, true)
+		},
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"join":  func(sep string, values []string) string { return strings.Join(values, sep) },
+		"indent": func(spaces int, s string) string {
+			pad := strings.Repeat(" ", spaces)
+			return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+		},
+		"default": func(def string, s string) string {
+			if s == "" {
+				return def
+			}
+			return s
+		},
+	}
+}
+
+// returns the text escaped for a Markdown table cell: backslashes and pipes are escaped and whitespace (including line breaks) is collapsed
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// returns a recommendation's field, or the result of a method with no arguments, by name
+func recommendationValue(r *DensifyRecommendation, name string) (reflect.Value, error) {
+	v := reflect.ValueOf(r)
+	if field := v.Elem().FieldByName(name); field.IsValid() {
+		return field, nil
+	}
+	if method := v.MethodByName(name); method.IsValid() && method.Type().NumIn() == 0 && method.Type().NumOut() == 1 {
+		return method.Call(nil)[0], nil
+	}
+	return reflect.Value{}, fmt.Errorf("recommendations have no field or method '%s'", name)
+}
+
+// returns true if a sorts before b; money sorts by amount, numbers numerically and anything else by its text
+func lessValue(a reflect.Value, b reflect.Value) bool {
+	if ma, ok := a.Interface().(Money); ok {
+		return ma.Micros < b.Interface().(Money).Micros
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	default:
+		return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+	}
+}
+
+// returns a copy of the recommendations sorted (stably) by a field or method, lowest first
+func sortRecommendationsBy(name string, recos []DensifyRecommendation) ([]DensifyRecommendation, error) {
+	values := make([]reflect.Value, len(recos))
+	for i := 0; i < len(recos); i++ {
+		v, err := recommendationValue(&recos[i], name)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	indexes := make([]int, len(recos))
+	for i := 0; i < len(indexes); i++ {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return lessValue(values[indexes[i]], values[indexes[j]])
+	})
+	sorted := make([]DensifyRecommendation, len(recos))
+	for i := 0; i < len(indexes); i++ {
+		sorted[i] = recos[indexes[i]]
+	}
+	return sorted, nil
+}
+
+// returns the kubernetes (or cloud) recommendations
+func filterRecommendations(recos []DensifyRecommendation, kubernetes bool) []DensifyRecommendation {
+	filtered := []DensifyRecommendation{}
+	for i := 0; i < len(recos); i++ {
+		if recos[i].isKubernetes() == kubernetes {
+			filtered = append(filtered, recos[i])
+		}
+	}
+	return filtered
+}
+
+// returns a copy of the recommendations in reverse order
+func reverseRecommendations(recos []DensifyRecommendation) []DensifyRecommendation {
+	reversed := make([]DensifyRecommendation, len(recos))
+	for i := 0; i < len(recos); i++ {
+		reversed[len(recos)-1-i] = recos[i]
+	}
+	return reversed
+}
+
+// returns the recommendations grouped by the text of a field or method, sorted by the group key
+func groupRecommendationsBy(name string, recos []DensifyRecommendation) ([]TemplateGroup, error) {
+	groups := map[string]*TemplateGroup{}
+	keys := []string{}
+	for i := 0; i < len(recos); i++ {
+		v, err := recommendationValue(&recos[i], name)
+		if err != nil {
+			return nil, err
+		}
+		key := fmt.Sprint(v.Interface())
+		if groups[key] == nil {
+			groups[key] = &TemplateGroup{Key: key}
+			keys = append(keys, key)
+		}
+		groups[key].Recommendations = append(groups[key].Recommendations, recos[i])
+	}
+	sort.Strings(keys)
+	result := make([]TemplateGroup, len(keys))
+	for i := 0; i < len(keys); i++ {
+		result[i] = *groups[keys[i]]
+	}
+	return result, nil
+}
+
+// Parse a template with the helper functions (see TemplateFuncs).
+func ParseTemplate(name string, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs()).Option("missingkey=error").Parse(text)
+}
+
+// Render the data with a template's text.
+func RenderTemplate(w io.Writer, text string, data *TemplateData) error {
+	tmpl, err := ParseTemplate("template", text)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// Returns the names of the bundled templates, ex. markdown-table.md.
+func BundledTemplateNames() []string {
+	entries, _ := bundledTemplates.ReadDir("templates")
+	names := make([]string, 0, len(entries))
+	for i := 0; i < len(entries); i++ {
+		names = append(names, strings.TrimSuffix(entries[i].Name(), ".tmpl"))
+	}
+	return names
+}
+
+// Returns the text of a bundled template, ex. to use as a starting point for your own.
+func BundledTemplate(name string) (string, error) {
+	text, err := bundledTemplates.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		return "", fmt.Errorf("invalid bundled template '%s'; must be one of: %s", name, strings.Join(BundledTemplateNames(), ", "))
+	}
+	return string(text), nil
+}
+
+// Render the data with a bundled template (see BundledTemplateNames).
+func RenderBundledTemplate(w io.Writer, name string, data *TemplateData) error {
+	text, err := BundledTemplate(name)
+	if err != nil {
+		return err
+	}
+	return RenderTemplate(w, text, data)
+}
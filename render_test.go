@@ -0,0 +1,172 @@
+package densify
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// cloud and kubernetes recommendations for the template tests
+func renderTestRecommendations() []DensifyRecommendation {
+	money := func(s string) Money {
+		m, _ := ParseMoney(s, "", PeriodMonthly)
+		return m
+	}
+	return []DensifyRecommendation{
+		{EntityId: "e1", Name: "web|prod", Region: "us-east-1", CurrentType: "m5.xlarge", RecommendedType: "m5.large", ApprovalType: ApprovalAll, SavingsEstimate: money("40")},
+		{EntityId: "e2", Name: "api-1", Region: "us-west-2", CurrentType: "t3.large", RecommendedType: "t3.medium", ApprovalType: ApprovalNotApproved, SavingsEstimate: money("10"),
+			Guardrails: DensifyGuardrails{Targets: []DensifyGuardrailsTarget{{InstanceType: "t3.medium", BlendedScore: 80, Compatibility: CompatibilityOK, CatalogCost: Money{Micros: 41600}}}}},
+		{Cluster: "prod", Namespace: "shop", ControllerType: "deployment", PodService: "cart", Container: "app", Region: "us-east-1",
+			CurrentCpuRequest: 500, RecommendedCpuRequest: 250, CurrentMemRequest: 1024, RecommendedMemRequest: 512, EstimatedSavings: money("25")},
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	hourly, _ := ParseMoney("0.1", "", PeriodHourly)
+	tests := []struct {
+		text string
+		data interface{}
+		want string
+	}{
+		{`{{money .}}`, Money{Micros: 1234567}, "1.23"},
+		{`{{moneyf 4 .}}`, Money{Micros: 1234567}, "1.2346"},
+		{`{{money (monthly .)}} {{money (annual .)}} {{money (hourly (monthly .))}}`, hourly, "73.00 876.00 0.10"},
+		{`{{cpu .}} {{millicores .}} {{cores .}}`, CpuQuantity(1500), "1500m 1500 1.5"},
+		{`{{mem .}} {{mib .}}`, MemQuantity(2048), "2Gi 2048"},
+		{`{{quote .}} {{squote .}}`, `it's "x"`, `"it's \"x\"" 'it''s "x"'`},
+		{`{{hclQuote .}}`, "${x}", `"$${x}"`},
+		{`{{json .}}`, map[string]int{"a": 1}, `{"a":1}`},
+		{`{{mdEscape .}}`, "a|b\\c\nd", `a\|b\\c d`},
+		{`{{alnum .}}`, "web-01.prod", "web01prod"},
+		{`{{lower .}} {{upper .}}`, "Ab", "ab AB"},
+		{`{{join ", " .}}`, []string{"a", "b"}, "a, b"},
+		{`{{indent 2 .}}`, "a\nb", "  a\n  b"},
+		{`{{default "none" .}}|{{default "none" "x"}}`, "", "none|x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			tmpl, err := ParseTemplate("test", tt.text)
+			if err != nil {
+				t.Fatalf("ParseTemplate() error: %v", err)
+			}
+			var sb strings.Builder
+			if err := tmpl.Execute(&sb, tt.data); err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("%s = %q, want %q", tt.text, sb.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRecommendationTemplateFuncs(t *testing.T) {
+	recos := renderTestRecommendations()
+	data, err := NewTemplateData(&recos)
+	if err != nil {
+		t.Fatalf("NewTemplateData() error: %v", err)
+	}
+	tests := []struct {
+		text   string
+		want   string
+		hasErr bool
+	}{
+		{`{{range sortBy "Name" .Recommendations}}{{.Name}},{{end}}`, ",api-1,web|prod,", false},
+		{`{{range sortBy "MonthlySavings" .Recommendations | reverse}}{{money .MonthlySavings}},{{end}}`, "40.00,25.00,10.00,", false},
+		{`{{range sortBy "CurrentCpuRequest" (kubernetes .Recommendations)}}{{.PodService}}{{end}}`, "cart", false},
+		{`{{range cloud .Recommendations}}{{.EntityId}},{{end}}`, "e1,e2,", false},
+		{`{{range groupBy "Region" .Recommendations}}{{.Key}}={{len .Recommendations}},{{end}}`, "us-east-1=2,us-west-2=1,", false},
+		{`{{range groupBy "GetApprovedType" (cloud .Recommendations)}}{{.Key}},{{end}}`, "m5.large,t3.large,", false},
+		{`{{.Summary.Totals.Count}}`, "3", false},
+		{`{{range sortBy "Owner" .Recommendations}}{{end}}`, "", true},
+		{`{{range groupBy "Owner" .Recommendations}}{{end}}`, "", true},
+		{`{{.Owner}}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var sb strings.Builder
+			err := RenderTemplate(&sb, tt.text, data)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("RenderTemplate() = %s, want an error", sb.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderTemplate() error: %v", err)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", sb.String(), tt.want)
+			}
+		})
+	}
+	// the helpers return copies, so the data isn't reordered
+	if data.Recommendations[0].Name != "web|prod" {
+		t.Errorf("sortBy reordered the data: %s first", data.Recommendations[0].Name)
+	}
+}
+
+func TestBundledTemplates(t *testing.T) {
+	recos := renderTestRecommendations()
+	data, err := NewTemplateData(&recos)
+	if err != nil {
+		t.Fatalf("NewTemplateData() error: %v", err)
+	}
+	names := BundledTemplateNames()
+	if strings.Join(names, ",") != "ansible-vars.yaml,cloudformation-params.json,guardrails-table.md,markdown-table.md" {
+		t.Errorf("BundledTemplateNames() = %v", names)
+	}
+	render := func(name string) string {
+		t.Helper()
+		var sb strings.Builder
+		if err := RenderBundledTemplate(&sb, name, data); err != nil {
+			t.Fatalf("RenderBundledTemplate(%s) error: %v", name, err)
+		}
+		return sb.String()
+	}
+
+	var ansible struct {
+		Recos map[string]map[string]string `yaml:"densify_recommendations"`
+	}
+	out := render("ansible-vars.yaml")
+	if err := yaml.Unmarshal([]byte(out), &ansible); err != nil {
+		t.Fatalf("ansible-vars.yaml doesn't parse: %v\n%s", err, out)
+	}
+	if ansible.Recos["web|prod"]["approved_type"] != "m5.large" || ansible.Recos["prod/shop/deployment/cart/app"]["cpu_request"] != "250m" {
+		t.Errorf("ansible-vars.yaml = %v", ansible.Recos)
+	}
+
+	var params []map[string]string
+	out = render("cloudformation-params.json")
+	if err := json.Unmarshal([]byte(out), &params); err != nil {
+		t.Fatalf("cloudformation-params.json doesn't parse: %v\n%s", err, out)
+	}
+	if len(params) != 2 || params[0]["ParameterKey"] != "api1InstanceType" || params[0]["ParameterValue"] != "t3.large" || params[1]["ParameterKey"] != "webprodInstanceType" {
+		t.Errorf("cloudformation-params.json = %v", params)
+	}
+
+	out = render("markdown-table.md")
+	rows := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "| ") && !strings.HasPrefix(line, "| Name") && !strings.HasPrefix(line, "| ---") {
+			rows = append(rows, line)
+		}
+	}
+	if len(rows) != 3 || !strings.HasPrefix(rows[0], `| web\|prod |`) || !strings.HasPrefix(rows[1], "| prod/shop/deployment/cart/app |") {
+		t.Errorf("markdown-table.md rows = %q", rows)
+	}
+	if !strings.Contains(out, "3 recommendation(s), saving 75.00 USD per month.") {
+		t.Errorf("markdown-table.md has no summary:\n%s", out)
+	}
+
+	out = render("guardrails-table.md")
+	if !strings.Contains(out, "## api-1 (t3.large)") || !strings.Contains(out, "| t3.medium | 80 | OK | 0 | 0.0416 |") || strings.Contains(out, "web") {
+		t.Errorf("guardrails-table.md =\n%s", out)
+	}
+
+	if _, err := BundledTemplate("html"); err == nil {
+		t.Errorf("BundledTemplate(html) didn't return an error")
+	}
+}
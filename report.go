@@ -0,0 +1,443 @@
+package densify
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the format of a report
+type ReportFormat string
+
+const (
+	ReportMarkdown ReportFormat = "markdown" // GitHub-flavored Markdown, ex. for pull request comments
+	ReportHTML     ReportFormat = "html"     // a self-contained HTML page, ex. for emails
+)
+
+// a section of a report
+type ReportSection string
+
+const (
+	SectionSummary         ReportSection = "summary"         // the totals and the number of each recommendation type
+	SectionGroups          ReportSection = "groups"          // the totals per account (cloud) and per cluster (kubernetes)
+	SectionTopSavings      ReportSection = "topSavings"      // the recommendations with the highest savings
+	SectionGuardrails      ReportSection = "guardrails"      // the guardrail alternatives of the instances with the highest savings
+	SectionContainerSizing ReportSection = "containerSizing" // the containers whose requests or limits are recommended to change
+)
+
+// the size limit of a GitHub comment, for MaxBytes
+const GitHubCommentMaxBytes = 65536
+
+// options for GenerateReport
+type ReportOptions struct {
+	Title    string          // defaults to "Densify rightsizing report"
+	Sections []ReportSection // the sections, in order; defaults to all of them
+	TopN     int             // the number of instances in the top savings and guardrails sections; defaults to 10
+	MaxBytes int             // the maximum size of the report; rows and sections that don't fit are left out, with a note. Zero is no limit
+}
+
+// a report table; rows are already formatted
+type reportTable struct {
+	headers []string
+	right   []bool // right-align the column, ex. for numbers
+	rows    [][]string
+}
+
+// a block of a report: a heading, a paragraph or a table
+type reportBlock struct {
+	level int // the heading level, for a heading
+	text  string
+	table *reportTable
+}
+
+// renders the blocks of a report in a format
+type reportRenderer interface {
+	begin(title string) string
+	end() string
+	heading(level int, text string) string
+	paragraph(text string) string
+	tableStart(t *reportTable) string
+	tableRow(t *reportTable, row []string) string
+	tableEnd() string
+}
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) begin(title string) string { return "# " + markdownEscape(title) + "\n" }
+func (markdownRenderer) end() string               { return "" }
+func (markdownRenderer) heading(level int, text string) string {
+	return "\n" + strings.Repeat("#", level) + " " + markdownEscape(text) + "\n"
+}
+func (markdownRenderer) paragraph(text string) string { return "\n" + markdownEscape(text) + "\n" }
+func (markdownRenderer) tableStart(t *reportTable) string {
+	var sb strings.Builder
+	sb.WriteString("\n|")
+	for i := 0; i < len(t.headers); i++ {
+		sb.WriteString(" " + markdownEscape(t.headers[i]) + " |")
+	}
+	sb.WriteString("\n|")
+	for i := 0; i < len(t.headers); i++ {
+		if t.right[i] {
+			sb.WriteString(" ---: |")
+		} else {
+			sb.WriteString(" --- |")
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+func (markdownRenderer) tableRow(t *reportTable, row []string) string {
+	var sb strings.Builder
+	sb.WriteString("|")
+	for i := 0; i < len(row); i++ {
+		sb.WriteString(" " + markdownEscape(row[i]) + " |")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+func (markdownRenderer) tableEnd() string { return "" }
+
+type htmlRenderer struct{}
+
+const reportStyle = `body{font-family:-apple-system,"Segoe UI",Helvetica,Arial,sans-serif;color:#1f2328;margin:2em}` +
+	`table{border-collapse:collapse;margin:1em 0}th,td{border:1px solid #d0d7de;padding:4px 10px}th{background:#f6f8fa}` +
+	`td.num,th.num{text-align:right}`
+
+func (htmlRenderer) begin(title string) string {
+	title = html.EscapeString(title)
+	return "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>" + title + "</title>\n<style>" + reportStyle +
+		"</style>\n</head>\n<body>\n<h1>" + title + "</h1>\n"
+}
+func (htmlRenderer) end() string { return "</body>\n</html>\n" }
+func (htmlRenderer) heading(level int, text string) string {
+	return fmt.Sprintf("<h%d>%s</h%d>\n", level, html.EscapeString(text), level)
+}
+func (htmlRenderer) paragraph(text string) string { return "<p>" + html.EscapeString(text) + "</p>\n" }
+func (htmlRenderer) tableStart(t *reportTable) string {
+	var sb strings.Builder
+	sb.WriteString("<table>\n<tr>")
+	for i := 0; i < len(t.headers); i++ {
+		sb.WriteString(htmlCell("th", t.headers[i], t.right[i]))
+	}
+	sb.WriteString("</tr>\n")
+	return sb.String()
+}
+func (htmlRenderer) tableRow(t *reportTable, row []string) string {
+	var sb strings.Builder
+	sb.WriteString("<tr>")
+	for i := 0; i < len(row); i++ {
+		sb.WriteString(htmlCell("td", row[i], t.right[i]))
+	}
+	sb.WriteString("</tr>\n")
+	return sb.String()
+}
+func (htmlRenderer) tableEnd() string { return "</table>\n" }
+
+func htmlCell(tag string, text string, right bool) string {
+	if right {
+		return "<" + tag + " class=\"num\">" + html.EscapeString(text) + "</" + tag + ">"
+	}
+	return "<" + tag + ">" + html.EscapeString(text) + "</" + tag + ">"
+}
+
+// Generate a human-readable rightsizing report from (container-level) recommendations, ex. from GetDensifyRecommendations. Costs and
+// savings are monthly. The options can be nil.
+func GenerateReport(recos *[]DensifyRecommendation, format ReportFormat, opts *ReportOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &ReportOptions{}
+	}
+	var renderer reportRenderer
+	switch format {
+	case ReportMarkdown:
+		renderer = markdownRenderer{}
+	case ReportHTML:
+		renderer = htmlRenderer{}
+	default:
+		return nil, fmt.Errorf("invalid report format '%s'; must be one of: markdown, html", format)
+	}
+	title := opts.Title
+	if title == "" {
+		title = "Densify rightsizing report"
+	}
+	topN := opts.TopN
+	if topN <= 0 {
+		topN = 10
+	}
+	sections := opts.Sections
+	if len(sections) == 0 {
+		sections = []ReportSection{SectionSummary, SectionGroups, SectionTopSavings, SectionGuardrails, SectionContainerSizing}
+	}
+	if recos == nil {
+		recos = &[]DensifyRecommendation{}
+	}
+
+	blocks := []reportBlock{}
+	for i := 0; i < len(sections); i++ {
+		var sectionBlocks []reportBlock
+		var err error
+		switch sections[i] {
+		case SectionSummary:
+			sectionBlocks, err = summaryReportBlocks(recos)
+		case SectionGroups:
+			sectionBlocks, err = groupsReportBlocks(recos)
+		case SectionTopSavings:
+			sectionBlocks, err = topSavingsReportBlocks(recos, topN)
+		case SectionGuardrails:
+			sectionBlocks = guardrailsReportBlocks(recos, topN)
+		case SectionContainerSizing:
+			sectionBlocks = containerSizingReportBlocks(recos)
+		default:
+			return nil, fmt.Errorf("invalid report section '%s'; must be one of: summary, groups, topSavings, guardrails, containerSizing", sections[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, sectionBlocks...)
+	}
+	return []byte(renderReport(renderer, title, blocks, opts.MaxBytes)), nil
+}
+
+// renders the blocks, leaving out the rows and blocks that don't fit in maxBytes (if it's set) and noting how many rows were left out
+func renderReport(renderer reportRenderer, title string, blocks []reportBlock, maxBytes int) string {
+	var sb strings.Builder
+	sb.WriteString(renderer.begin(title))
+	end := renderer.end()
+	// leave room for the truncation note
+	budget := maxBytes - len(end) - len(renderer.paragraph(fmt.Sprintf("Report truncated to fit %d bytes: %d table row(s) and %d block(s) left out.", maxBytes, maxBytes, maxBytes)))
+	fits := func(s string) bool {
+		return maxBytes <= 0 || sb.Len()+len(s) <= budget
+	}
+
+	omittedRows, omittedBlocks := 0, 0
+	for i := 0; i < len(blocks); i++ {
+		b := blocks[i]
+		if omittedRows > 0 || omittedBlocks > 0 {
+			// once something is left out, leave out the rest, so the report doesn't skip around
+			omittedBlocks++
+			continue
+		}
+		switch {
+		case b.table != nil:
+			start, tableEnd := renderer.tableStart(b.table), renderer.tableEnd()
+			if len(b.table.rows) == 0 || !fits(start+renderer.tableRow(b.table, b.table.rows[0])+tableEnd) {
+				omittedBlocks++
+				continue
+			}
+			sb.WriteString(start)
+			for j := 0; j < len(b.table.rows); j++ {
+				row := renderer.tableRow(b.table, b.table.rows[j])
+				if !fits(row + tableEnd) {
+					omittedRows = len(b.table.rows) - j
+					break
+				}
+				sb.WriteString(row)
+			}
+			sb.WriteString(tableEnd)
+		case b.level > 0:
+			s := renderer.heading(b.level, b.text)
+			next := s
+			if i+1 < len(blocks) && blocks[i+1].table != nil && len(blocks[i+1].table.rows) > 0 {
+				// keep a heading with (at least the first row of) its table
+				t := blocks[i+1].table
+				next += renderer.tableStart(t) + renderer.tableRow(t, t.rows[0]) + renderer.tableEnd()
+			}
+			if !fits(next) {
+				omittedBlocks++
+				continue
+			}
+			sb.WriteString(s)
+		default:
+			s := renderer.paragraph(b.text)
+			if !fits(s) {
+				omittedBlocks++
+				continue
+			}
+			sb.WriteString(s)
+		}
+	}
+	if omittedRows > 0 || omittedBlocks > 0 {
+		sb.WriteString(renderer.paragraph(fmt.Sprintf("Report truncated to fit %d bytes: %d table row(s) and %d block(s) left out.", maxBytes, omittedRows, omittedBlocks)))
+	}
+	sb.WriteString(end)
+	return sb.String()
+}
+
+// returns the totals as a row: count, current cost, recommended cost and savings
+func totalsRow(name string, t *RecommendationTotals) []string {
+	return []string{name, strconv.Itoa(t.Count), t.CurrentCost.Amount(), t.RecommendedCost.Amount(), t.Savings.Amount()}
+}
+
+func summaryReportBlocks(recos *[]DensifyRecommendation) ([]reportBlock, error) {
+	summary, err := SummarizeRecommendations(recos, nil)
+	if err != nil {
+		return nil, err
+	}
+	totals := summary.Totals
+	blocks := []reportBlock{
+		{level: 2, text: "Summary"},
+		{text: fmt.Sprintf("%d recommendation(s), with savings of %s %s per month.", totals.Count, totals.Savings.Amount(), totals.Savings.CurrencyCode())},
+	}
+	types := make([]string, 0, len(totals.RecommendationTypes))
+	for t := range totals.RecommendationTypes {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+	table := &reportTable{headers: []string{"Recommendation type", "Count"}, right: []bool{false, true}}
+	for i := 0; i < len(types); i++ {
+		name := types[i]
+		if name == "" {
+			name = "(none)"
+		}
+		table.rows = append(table.rows, []string{name, strconv.Itoa(totals.RecommendationTypes[RecommendationType(types[i])])})
+	}
+	return append(blocks, reportBlock{table: table}), nil
+}
+
+func groupsReportBlocks(recos *[]DensifyRecommendation) ([]reportBlock, error) {
+	blocks := []reportBlock{}
+	headers := []string{"", "Count", "Current cost", "Recommended cost", "Savings"}
+	right := []bool{false, true, true, true, true}
+	sets := []struct {
+		title      string
+		column     string
+		groupBy    SummaryGroupBy
+		kubernetes bool
+	}{
+		{"Savings by account", "Account", GroupByAccount, false},
+		{"Savings by cluster", "Cluster", GroupByCluster, true},
+	}
+	for i := 0; i < len(sets); i++ {
+		filtered := filterRecommendations(*recos, sets[i].kubernetes)
+		if len(filtered) == 0 {
+			continue
+		}
+		summary, err := SummarizeRecommendations(&filtered, &SummaryOptions{GroupBy: []SummaryGroupBy{sets[i].groupBy}})
+		if err != nil {
+			return nil, err
+		}
+		table := &reportTable{headers: append([]string{}, headers...), right: right}
+		table.headers[0] = sets[i].column
+		for j := 0; j < len(summary.Groups); j++ {
+			group := &summary.Groups[j]
+			table.rows = append(table.rows, totalsRow(group.Values[sets[i].groupBy], &group.Totals))
+		}
+		blocks = append(blocks, reportBlock{level: 2, text: sets[i].title}, reportBlock{table: table})
+	}
+	return blocks, nil
+}
+
+func topSavingsReportBlocks(recos *[]DensifyRecommendation, topN int) ([]reportBlock, error) {
+	summary, err := SummarizeRecommendations(recos, &SummaryOptions{TopN: topN})
+	if err != nil {
+		return nil, err
+	}
+	table := &reportTable{
+		headers: []string{"Name", "Recommendation type", "Current", "Recommended", "Savings"},
+		right:   []bool{false, false, false, false, true},
+	}
+	for i := 0; i < len(summary.TopContributors); i++ {
+		c := &summary.TopContributors[i]
+		if c.Savings.Micros <= 0 {
+			break
+		}
+		table.rows = append(table.rows, []string{c.Name, string(c.RecommendationType), c.CurrentType, c.RecommendedType, c.Savings.Amount()})
+	}
+	if len(table.rows) == 0 {
+		return []reportBlock{}, nil
+	}
+	// there can be fewer rows than topN, since only recommendations with savings are listed
+	return []reportBlock{{level: 2, text: fmt.Sprintf("Top %d savings opportunities", len(table.rows))}, {table: table}}, nil
+}
+
+func guardrailsReportBlocks(recos *[]DensifyRecommendation, topN int) []reportBlock {
+	withTargets := []*DensifyRecommendation{}
+	for i := 0; i < len(*recos); i++ {
+		if len((*recos)[i].Guardrails.Targets) > 0 {
+			withTargets = append(withTargets, &(*recos)[i])
+		}
+	}
+	if len(withTargets) == 0 {
+		return []reportBlock{}
+	}
+	sort.SliceStable(withTargets, func(i, j int) bool {
+		return withTargets[i].MonthlySavings().Micros > withTargets[j].MonthlySavings().Micros
+	})
+
+	blocks := []reportBlock{{level: 2, text: "Guardrail alternatives"}}
+	for i := 0; i < len(withTargets) && i < topN; i++ {
+		r := withTargets[i]
+		table := &reportTable{
+			headers: []string{"Instance type", "Blended score", "Compatibility", "% of optimal cost", "Hourly cost"},
+			right:   []bool{false, true, false, true, true},
+		}
+		for j := 0; j < len(r.Guardrails.Targets); j++ {
+			t := &r.Guardrails.Targets[j]
+			compatibility := string(t.Compatibility)
+			if len(t.IncompatibilityReason) > 0 {
+				compatibility += " (" + strings.Join(t.IncompatibilityReason, "; ") + ")"
+			}
+			table.rows = append(table.rows, []string{
+				t.InstanceType, strconv.Itoa(t.BlendedScore), compatibility,
+				strconv.FormatFloat(float64(t.PercentOptimalCost), 'f', -1, 64), t.CatalogCost.Format(4),
+			})
+		}
+		blocks = append(blocks, reportBlock{level: 3, text: fmt.Sprintf("%s (%s)", r.Name, r.CurrentType)}, reportBlock{table: table})
+	}
+	return blocks
+}
+
+// returns "current → recommended", or just the current value if it isn't changing; unset values are blank, or "none" if they're changing
+func sizingChange(current string, recommended string, changing bool) string {
+	if current == "0" {
+		current = ""
+		if changing {
+			current = "none"
+		}
+	}
+	if !changing {
+		return current
+	}
+	return current + " → " + recommended
+}
+
+func containerSizingReportBlocks(recos *[]DensifyRecommendation) []reportBlock {
+	table := &reportTable{
+		headers: []string{"Container", "CPU request", "CPU limit", "Memory request", "Memory limit", "Savings"},
+		right:   []bool{false, false, false, false, false, true},
+	}
+	sorted := filterRecommendations(*recos, true)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].summaryName() < sorted[j].summaryName()
+	})
+	for i := 0; i < len(sorted); i++ {
+		pod := sorted[i].asPod()
+		for j := 0; j < len(pod.Containers); j++ {
+			c := &pod.Containers[j]
+			cpuRequest := !c.RecommendedCpuRequest.IsZero() && c.RecommendedCpuRequest != c.CurrentCpuRequest
+			cpuLimit := !c.RecommendedCpuLimit.IsZero() && c.RecommendedCpuLimit != c.CurrentCpuLimit
+			memRequest := !c.RecommendedMemRequest.IsZero() && c.RecommendedMemRequest != c.CurrentMemRequest
+			memLimit := !c.RecommendedMemLimit.IsZero() && c.RecommendedMemLimit != c.CurrentMemLimit
+			if !cpuRequest && !cpuLimit && !memRequest && !memLimit {
+				continue
+			}
+			savings := c.EstimatedSavings
+			if len(pod.Containers) == 1 {
+				savings = sorted[i].MonthlySavings()
+			}
+			table.rows = append(table.rows, []string{
+				sorted[i].WorkloadKey().ContainerKey(c.Container).String(),
+				sizingChange(c.CurrentCpuRequest.String(), c.RecommendedCpuRequest.String(), cpuRequest),
+				sizingChange(c.CurrentCpuLimit.String(), c.RecommendedCpuLimit.String(), cpuLimit),
+				sizingChange(c.CurrentMemRequest.String(), c.RecommendedMemRequest.String(), memRequest),
+				sizingChange(c.CurrentMemLimit.String(), c.RecommendedMemLimit.String(), memLimit),
+				savings.Monthly().Amount(),
+			})
+		}
+	}
+	if len(table.rows) == 0 {
+		return []reportBlock{}
+	}
+	return []reportBlock{{level: 2, text: "Container sizing changes"}, {table: table}}
+}
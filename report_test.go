@@ -0,0 +1,112 @@
+package densify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateReportTopSavings(t *testing.T) {
+	recos := renderTestRecommendations()
+	tests := []struct {
+		name    string
+		topN    int
+		heading string
+		rows    int
+	}{
+		{"default", 0, "## Top 3 savings opportunities", 3},
+		{"fewer than the recommendations", 2, "## Top 2 savings opportunities", 2},
+		{"more than the recommendations", 25, "## Top 3 savings opportunities", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := GenerateReport(&recos, ReportMarkdown, &ReportOptions{Sections: []ReportSection{SectionTopSavings}, TopN: tt.topN})
+			if err != nil {
+				t.Fatalf("GenerateReport() error: %v", err)
+			}
+			report := string(out)
+			if !strings.Contains(report, tt.heading+"\n") {
+				t.Errorf("report has no %q heading:\n%s", tt.heading, report)
+			}
+			// the header and separator rows, then the rows
+			if rows := strings.Count(report, "\n|") - 2; rows != tt.rows {
+				t.Errorf("got %d rows, want %d:\n%s", rows, tt.rows, report)
+			}
+		})
+	}
+
+	// recommendations without savings aren't listed, and the heading counts the rows
+	noSavings := []DensifyRecommendation{recos[0], {Name: "idle", CurrentType: "m5.large"}}
+	out, err := GenerateReport(&noSavings, ReportMarkdown, &ReportOptions{Sections: []ReportSection{SectionTopSavings}})
+	if err != nil {
+		t.Fatalf("GenerateReport() error: %v", err)
+	}
+	if !strings.Contains(string(out), "## Top 1 savings opportunities\n") || strings.Contains(string(out), "idle") {
+		t.Errorf("report =\n%s", out)
+	}
+}
+
+func TestGenerateReport(t *testing.T) {
+	recos := renderTestRecommendations()
+	recos[0].RecommendationType = "<b>Downsize</b>"
+	tests := []struct {
+		format   ReportFormat
+		contains []string
+	}{
+		{ReportMarkdown, []string{
+			"# Densify rightsizing report\n",
+			"## Summary\n\n3 recommendation(s), with savings of 75.00 USD per month.\n",
+			"## Savings by cluster\n",
+			"| web\\|prod | <b>Downsize</b> | m5.xlarge | m5.large | 40.00 |",
+			"## Guardrail alternatives\n\n### api-1 (t3.large)\n",
+			"| prod/shop/deployment/cart/app | 500m → 250m |  | 1Gi → 512Mi |  | 25.00 |",
+		}},
+		{ReportHTML, []string{
+			"<!DOCTYPE html>",
+			"<h2>Top 3 savings opportunities</h2>",
+			"<td>web|prod</td><td>&lt;b&gt;Downsize&lt;/b&gt;</td>",
+			"<td class=\"num\">40.00</td>",
+			"</body>\n</html>\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			out, err := GenerateReport(&recos, tt.format, nil)
+			if err != nil {
+				t.Fatalf("GenerateReport() error: %v", err)
+			}
+			for i := 0; i < len(tt.contains); i++ {
+				if !strings.Contains(string(out), tt.contains[i]) {
+					t.Errorf("report has no %q:\n%s", tt.contains[i], out)
+				}
+			}
+		})
+	}
+
+	if _, err := GenerateReport(&recos, "pdf", nil); err == nil {
+		t.Errorf("GenerateReport(pdf) didn't return an error")
+	}
+	if _, err := GenerateReport(&recos, ReportMarkdown, &ReportOptions{Sections: []ReportSection{"costs"}}); err == nil {
+		t.Errorf("GenerateReport() with an invalid section didn't return an error")
+	}
+}
+
+func TestGenerateReportMaxBytes(t *testing.T) {
+	recos := []DensifyRecommendation{}
+	for i := 0; i < 50; i++ {
+		savings, _ := ParseMoney("10", "", PeriodMonthly)
+		recos = append(recos, DensifyRecommendation{Name: strings.Repeat("x", 40) + string(rune('a'+i%26)), CurrentType: "m5.large", SavingsEstimate: savings})
+	}
+	for _, maxBytes := range []int{600, 2000, GitHubCommentMaxBytes} {
+		out, err := GenerateReport(&recos, ReportMarkdown, &ReportOptions{TopN: 50, MaxBytes: maxBytes})
+		if err != nil {
+			t.Fatalf("GenerateReport() error: %v", err)
+		}
+		if len(out) > maxBytes {
+			t.Errorf("report is %d bytes, want at most %d", len(out), maxBytes)
+		}
+		truncated := strings.Contains(string(out), "Report truncated to fit")
+		if truncated != (maxBytes < GitHubCommentMaxBytes) {
+			t.Errorf("MaxBytes %d: truncated = %v\n%s", maxBytes, truncated, out)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package densify
+
+// where the effective container requests and limits came from
+type SizingSource string
+
+const (
+	SizingRecommended SizingSource = "recommended" // the approved Densify recommendation
+	SizingCurrent     SizingSource = "current"     // the current values, since the recommendation isn't approved (or there is nothing else)
+	SizingFallback    SizingSource = "fallback"    // the query's fallback values, since there is no (mature) recommendation
+)
+
+// why the effective container requests and limits came from their source
+type SizingReason string
+
+const (
+	SizingReasonApproved         SizingReason = "approved"          // the recommendation is approved
+	SizingReasonNotApproved      SizingReason = "not-approved"      // the recommendation isn't approved, so the current values are kept
+	SizingReasonNoRecommendation SizingReason = "no-recommendation" // there is no recommendation yet
+	SizingReasonNotMature        SizingReason = "not-mature"        // the recommendation didn't pass the query's maturity policy
+	SizingReasonNoFallback       SizingReason = "no-fallback"       // there is no (mature) recommendation and no fallback values, so the current values are kept
+)
+
+// the requests and limits to apply to a container, and where they came from
+type EffectiveResources struct {
+	Container  string       `json:"container"`
+	CpuRequest CpuQuantity  `json:"cpuRequest"`
+	CpuLimit   CpuQuantity  `json:"cpuLimit"`
+	MemRequest MemQuantity  `json:"memRequest"`
+	MemLimit   MemQuantity  `json:"memLimit"`
+	Source     SizingSource `json:"source"`
+	Reason     SizingReason `json:"reason"`
+}
+
+// returns true if Densify has recommended any request or limit for the container
+func (c *DensifyContainerRecommendation) hasRecommendation() bool {
+	return !c.RecommendedCpuRequest.IsZero() || !c.RecommendedCpuLimit.IsZero() || !c.RecommendedMemRequest.IsZero() || !c.RecommendedMemLimit.IsZero()
+}
+
+// returns true if any fallback request or limit is set for the container
+func (c *DensifyContainerRecommendation) hasFallback() bool {
+	return !c.FallbackCpuRequest.IsZero() || !c.FallbackCpuLimit.IsZero() || !c.FallbackMemRequest.IsZero() || !c.FallbackMemLimit.IsZero()
+}
+
+// Returns the requests and limits to apply to the container: the recommended values if the recommendation is approved, the current values
+// if it isn't, and the fallback values if there is no recommendation (or it isn't mature, when the query has a maturity policy). Recommended
+// or fallback values that aren't set are filled in with the current values.
+func (c *DensifyContainerRecommendation) GetEffectiveResources() EffectiveResources {
+	current := EffectiveResources{
+		Container:  c.Container,
+		CpuRequest: c.CurrentCpuRequest,
+		CpuLimit:   c.CurrentCpuLimit,
+		MemRequest: c.CurrentMemRequest,
+		MemLimit:   c.CurrentMemLimit,
+		Source:     SizingCurrent,
+	}
+
+	reason := SizingReason("")
+	if !c.hasRecommendation() {
+		reason = SizingReasonNoRecommendation
+	} else if c.Maturity != nil && !c.Maturity.IsMature() {
+		reason = SizingReasonNotMature
+	}
+	if reason != "" {
+		if !c.hasFallback() {
+			current.Reason = SizingReasonNoFallback
+			return current
+		}
+		return current.withOverrides(c.FallbackCpuRequest, c.FallbackCpuLimit, c.FallbackMemRequest, c.FallbackMemLimit, SizingFallback, reason)
+	}
+
+	if !c.ApprovalType.IsApproved() {
+		current.Reason = SizingReasonNotApproved
+		return current
+	}
+	return current.withOverrides(c.RecommendedCpuRequest, c.RecommendedCpuLimit, c.RecommendedMemRequest, c.RecommendedMemLimit, SizingRecommended, SizingReasonApproved)
+}
+
+// returns a copy with the values that are set replacing the current ones
+func (e EffectiveResources) withOverrides(cpuRequest CpuQuantity, cpuLimit CpuQuantity, memRequest MemQuantity, memLimit MemQuantity, source SizingSource, reason SizingReason) EffectiveResources {
+	if !cpuRequest.IsZero() {
+		e.CpuRequest = cpuRequest
+	}
+	if !cpuLimit.IsZero() {
+		e.CpuLimit = cpuLimit
+	}
+	if !memRequest.IsZero() {
+		e.MemRequest = memRequest
+	}
+	if !memLimit.IsZero() {
+		e.MemLimit = memLimit
+	}
+	e.Source = source
+	e.Reason = reason
+	return e
+}
+
+// Returns the effective requests and limits of each container in a pod-level recommendation, in the same order as Containers.
+func (r *DensifyRecommendation) GetEffectiveResources() []EffectiveResources {
+	resources := make([]EffectiveResources, 0, len(r.Containers))
+	for i := 0; i < len(r.Containers); i++ {
+		resources = append(resources, r.Containers[i].GetEffectiveResources())
+	}
+	return resources
+}
+
+// sets the query's fallback requests and limits on each of the recommendation's containers
+func (q *DensifyAPIQuery) setContainerFallbacks(reco *DensifyRecommendation) {
+	// the fallback values are validated when the query is configured, so invalid values are left empty here
+	fallback, err := q.getFallbackResources()
+	if err != nil {
+		return
+	}
+	for i := 0; i < len(reco.Containers); i++ {
+		reco.Containers[i].FallbackCpuRequest = fallback.CpuRequest
+		reco.Containers[i].FallbackCpuLimit = fallback.CpuLimit
+		reco.Containers[i].FallbackMemRequest = fallback.MemRequest
+		reco.Containers[i].FallbackMemLimit = fallback.MemLimit
+	}
+}
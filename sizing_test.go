@@ -0,0 +1,114 @@
+package densify
+
+import (
+	"testing"
+)
+
+func TestGetEffectiveResources(t *testing.T) {
+	immature := &MaturityAssessment{Verdict: MaturityImmature, Reasons: []string{"days unchanged is 1; needs at least 7"}}
+	mature := &MaturityAssessment{Verdict: MaturityMature, Reasons: []string{}}
+	current := DensifyContainerRecommendation{Container: "web", CurrentCpuRequest: 500, CurrentCpuLimit: 1000, CurrentMemRequest: 512, CurrentMemLimit: 1024}
+	with := func(f func(c *DensifyContainerRecommendation)) DensifyContainerRecommendation {
+		c := current
+		f(&c)
+		return c
+	}
+	recommended := func(c *DensifyContainerRecommendation) {
+		c.RecommendedCpuRequest = 250
+		c.RecommendedMemLimit = 768
+	}
+	fallback := func(c *DensifyContainerRecommendation) {
+		c.FallbackCpuRequest = 100
+		c.FallbackCpuLimit = 200
+	}
+	tests := []struct {
+		name      string
+		container DensifyContainerRecommendation
+		want      EffectiveResources
+	}{
+		{"approved", with(func(c *DensifyContainerRecommendation) {
+			recommended(c)
+			c.ApprovalType = ApprovalAny
+		}), EffectiveResources{"web", 250, 1000, 512, 768, SizingRecommended, SizingReasonApproved}},
+		{"approved and mature", with(func(c *DensifyContainerRecommendation) {
+			recommended(c)
+			fallback(c)
+			c.ApprovalType = ApprovalAll
+			c.Maturity = mature
+		}), EffectiveResources{"web", 250, 1000, 512, 768, SizingRecommended, SizingReasonApproved}},
+		{"not approved", with(func(c *DensifyContainerRecommendation) {
+			recommended(c)
+			fallback(c)
+			c.ApprovalType = ApprovalNotApproved
+		}), EffectiveResources{"web", 500, 1000, 512, 1024, SizingCurrent, SizingReasonNotApproved}},
+		{"approval not set", with(recommended), EffectiveResources{"web", 500, 1000, 512, 1024, SizingCurrent, SizingReasonNotApproved}},
+		{"no recommendation", with(func(c *DensifyContainerRecommendation) {
+			fallback(c)
+			c.ApprovalType = ApprovalAny
+		}), EffectiveResources{"web", 100, 200, 512, 1024, SizingFallback, SizingReasonNoRecommendation}},
+		{"not mature", with(func(c *DensifyContainerRecommendation) {
+			recommended(c)
+			fallback(c)
+			c.ApprovalType = ApprovalAny
+			c.Maturity = immature
+		}), EffectiveResources{"web", 100, 200, 512, 1024, SizingFallback, SizingReasonNotMature}},
+		{"no recommendation or fallback", current, EffectiveResources{"web", 500, 1000, 512, 1024, SizingCurrent, SizingReasonNoFallback}},
+		{"not mature without a fallback", with(func(c *DensifyContainerRecommendation) {
+			recommended(c)
+			c.ApprovalType = ApprovalAny
+			c.Maturity = immature
+		}), EffectiveResources{"web", 500, 1000, 512, 1024, SizingCurrent, SizingReasonNoFallback}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.container.GetEffectiveResources(); got != tt.want {
+				t.Errorf("GetEffectiveResources() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	pod := DensifyRecommendation{Containers: []DensifyContainerRecommendation{tests[0].container, current}}
+	resources := pod.GetEffectiveResources()
+	if len(resources) != 2 || resources[0] != tests[0].want || resources[1].Reason != SizingReasonNoFallback {
+		t.Errorf("GetEffectiveResources() = %+v", resources)
+	}
+	if got := (&DensifyRecommendation{}).GetEffectiveResources(); got == nil || len(got) != 0 {
+		t.Errorf("GetEffectiveResources() without containers = %v, want an empty list", got)
+	}
+}
+
+func TestSetContainerFallbacks(t *testing.T) {
+	tests := []struct {
+		name  string
+		query DensifyAPIQuery
+		want  EffectiveResources // the fallback values set on each container
+	}{
+		{"all set", DensifyAPIQuery{FallbackCPURequest: "250m", FallbackCPULimit: "1", FallbackMemRequest: "256Mi", FallbackMemLimit: "1Gi"},
+			EffectiveResources{CpuRequest: 250, CpuLimit: 1000, MemRequest: 256, MemLimit: 1024}},
+		{"some set", DensifyAPIQuery{FallbackMemLimit: "2Gi"}, EffectiveResources{MemLimit: 2048}},
+		{"none set", DensifyAPIQuery{}, EffectiveResources{}},
+		{"invalid", DensifyAPIQuery{FallbackCPURequest: "lots", FallbackMemLimit: "1Gi"}, EffectiveResources{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reco := DensifyRecommendation{Containers: []DensifyContainerRecommendation{{Container: "web"}, {Container: "sidecar"}}}
+			tt.query.setContainerFallbacks(&reco)
+			for i := 0; i < len(reco.Containers); i++ {
+				c := reco.Containers[i]
+				got := EffectiveResources{CpuRequest: c.FallbackCpuRequest, CpuLimit: c.FallbackCpuLimit, MemRequest: c.FallbackMemRequest, MemLimit: c.FallbackMemLimit}
+				if got != tt.want {
+					t.Errorf("Containers[%d] fallbacks = %+v, want %+v", i, got, tt.want)
+				}
+			}
+		})
+	}
+
+	// the fallback values reach the effective resources of containers without a recommendation
+	reco := DensifyRecommendation{Containers: []DensifyContainerRecommendation{{Container: "web", CurrentCpuRequest: 500, CurrentMemLimit: 512}}}
+	query := DensifyAPIQuery{FallbackCPURequest: "250m"}
+	query.setContainerFallbacks(&reco)
+	want := EffectiveResources{"web", 250, 0, 0, 512, SizingFallback, SizingReasonNoRecommendation}
+	if got := reco.GetEffectiveResources(); len(got) != 1 || got[0] != want {
+		t.Errorf("GetEffectiveResources() = %+v, want %+v", got, want)
+	}
+}
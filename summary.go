@@ -0,0 +1,227 @@
+package densify
+
+import (
+	"fmt"
+	"sort"
+)
+
+// a recommendation field to group a summary by
+type SummaryGroupBy string
+
+const (
+	GroupByAccount            SummaryGroupBy = "account"
+	GroupByRegion             SummaryGroupBy = "region"
+	GroupByServiceType        SummaryGroupBy = "serviceType"
+	GroupByRecommendationType SummaryGroupBy = "recommendationType"
+	GroupByCluster            SummaryGroupBy = "cluster"
+	GroupByNamespace          SummaryGroupBy = "namespace"
+	GroupByControllerType     SummaryGroupBy = "controllerType"
+)
+
+// returns the recommendation's value for the group by field
+func (g SummaryGroupBy) value(r *DensifyRecommendation) (string, error) {
+	switch g {
+	case GroupByAccount:
+		if r.AccountId != "" {
+			return r.AccountId, nil
+		}
+		return r.AccountName, nil
+	case GroupByRegion:
+		return r.Region, nil
+	case GroupByServiceType:
+		return r.ServiceType, nil
+	case GroupByRecommendationType:
+		return string(r.RecommendationType), nil
+	case GroupByCluster:
+		return r.Cluster, nil
+	case GroupByNamespace:
+		return r.Namespace, nil
+	case GroupByControllerType:
+		return r.ControllerType, nil
+	default:
+		return "", g.validate()
+	}
+}
+
+// returns an error if the group by field isn't one of the supported fields
+func (g SummaryGroupBy) validate() error {
+	switch g {
+	case GroupByAccount, GroupByRegion, GroupByServiceType, GroupByRecommendationType, GroupByCluster, GroupByNamespace, GroupByControllerType:
+		return nil
+	default:
+		return fmt.Errorf("invalid summary group by '%s'; must be one of: account, region, serviceType, recommendationType, cluster, namespace, controllerType", g)
+	}
+}
+
+// options for SummarizeRecommendations
+type SummaryOptions struct {
+	GroupBy []SummaryGroupBy // the fields to group by, ex. account and region; empty only calculates the overall totals
+	TopN    int              // the number of top contributors (by savings) to return overall and for each group; zero returns none
+}
+
+// the totals for a set of recommendations; costs and savings are monthly
+type RecommendationTotals struct {
+	Count               int                        `json:"count"`
+	CurrentCost         Money                      `json:"currentCost"`
+	RecommendedCost     Money                      `json:"recommendedCost"`
+	Savings             Money                      `json:"savings"`
+	RecommendationTypes map[RecommendationType]int `json:"recommendationTypes"` // the number of recommendations of each type
+}
+
+// a single recommendation's contribution to the savings
+type SummaryContributor struct {
+	Name               string             `json:"name"` // the system name, or cluster/namespace/controllerType/podService/container for kubernetes
+	AccountId          string             `json:"accountId"`
+	Cluster            string             `json:"cluster"`
+	RecommendationType RecommendationType `json:"recommendationType"`
+	CurrentType        string             `json:"currentType"`
+	RecommendedType    string             `json:"recommendedType"`
+	Savings            Money              `json:"savings"`
+}
+
+// the totals for one combination of the group by values
+type SummaryGroup struct {
+	Values          map[SummaryGroupBy]string `json:"values"` // the group by field > value
+	Totals          RecommendationTotals      `json:"totals"`
+	TopContributors []SummaryContributor      `json:"topContributors"`
+}
+
+// returns the group's values joined with slashes, in the order of the group by fields, ex. "123456789012/us-east-1"; each value is path
+// escaped (as in WorkloadKey), so a value with a slash doesn't make two groups' keys the same
+func (g *SummaryGroup) Key(groupBy []SummaryGroupBy) string {
+	values := make([]string, len(groupBy))
+	for i := 0; i < len(groupBy); i++ {
+		values[i] = g.Values[groupBy[i]]
+	}
+	return formatKeyParts(values...)
+}
+
+// the result of SummarizeRecommendations
+type RecommendationSummary struct {
+	GroupBy         []SummaryGroupBy     `json:"groupBy"`
+	Totals          RecommendationTotals `json:"totals"`
+	Groups          []SummaryGroup       `json:"groups"` // sorted by savings, highest first
+	TopContributors []SummaryContributor `json:"topContributors"`
+}
+
+// returns the monthly savings of a recommendation; cloud recommendations have a savings estimate and containers have estimated savings
+func (r *DensifyRecommendation) MonthlySavings() Money {
+	if !r.SavingsEstimate.IsZero() {
+		return r.SavingsEstimate.Monthly()
+	}
+	return r.EstimatedSavings.Monthly()
+}
+
+// returns the name used to identify a recommendation in summaries and reports
+func (r *DensifyRecommendation) summaryName() string {
+	if r.isKubernetes() {
+		return r.ContainerKey().String()
+	}
+	return r.Name
+}
+
+// adds a recommendation to the totals
+func (t *RecommendationTotals) add(r *DensifyRecommendation) {
+	if t.RecommendationTypes == nil {
+		t.RecommendationTypes = map[RecommendationType]int{}
+	}
+	t.Count++
+	t.CurrentCost = t.CurrentCost.Add(r.CurrentCost)
+	t.RecommendedCost = t.RecommendedCost.Add(r.RecommendedCost)
+	t.Savings = t.Savings.Add(r.MonthlySavings())
+	t.RecommendationTypes[r.RecommendationType]++
+}
+
+// returns the top n contributors by savings (highest first, then by name)
+func topContributors(recos []*DensifyRecommendation, n int) []SummaryContributor {
+	contributors := []SummaryContributor{}
+	if n <= 0 {
+		return contributors
+	}
+	sorted := make([]*DensifyRecommendation, len(recos))
+	copy(sorted, recos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, sj := sorted[i].MonthlySavings().Micros, sorted[j].MonthlySavings().Micros
+		if si != sj {
+			return si > sj
+		}
+		return sorted[i].summaryName() < sorted[j].summaryName()
+	})
+	for i := 0; i < len(sorted) && i < n; i++ {
+		r := sorted[i]
+		contributors = append(contributors, SummaryContributor{
+			Name:               r.summaryName(),
+			AccountId:          r.AccountId,
+			Cluster:            r.Cluster,
+			RecommendationType: r.RecommendationType,
+			CurrentType:        r.CurrentType,
+			RecommendedType:    r.RecommendedType,
+			Savings:            r.MonthlySavings(),
+		})
+	}
+	return contributors
+}
+
+// Total the current cost, recommended cost and savings, and count the recommendation types, optionally grouped by one or more fields.
+// Use the (container-level) recommendations from GetDensifyRecommendations rather than pod-level workloads, so containers aren't missed.
+func SummarizeRecommendations(recos *[]DensifyRecommendation, opts *SummaryOptions) (*RecommendationSummary, error) {
+	if opts == nil {
+		opts = &SummaryOptions{}
+	}
+	for i := 0; i < len(opts.GroupBy); i++ {
+		if err := opts.GroupBy[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	summary := RecommendationSummary{
+		GroupBy: opts.GroupBy,
+		Totals:  RecommendationTotals{RecommendationTypes: map[RecommendationType]int{}},
+		Groups:  []SummaryGroup{},
+	}
+	if recos == nil {
+		summary.TopContributors = []SummaryContributor{}
+		return &summary, nil
+	}
+
+	all := []*DensifyRecommendation{}
+	groups := map[string]*SummaryGroup{}
+	groupRecos := map[string][]*DensifyRecommendation{}
+	for i := 0; i < len(*recos); i++ {
+		r := &(*recos)[i]
+		all = append(all, r)
+		summary.Totals.add(r)
+		if len(opts.GroupBy) == 0 {
+			continue
+		}
+
+		values := map[SummaryGroupBy]string{}
+		for j := 0; j < len(opts.GroupBy); j++ {
+			v, err := opts.GroupBy[j].value(r)
+			if err != nil {
+				return nil, err
+			}
+			values[opts.GroupBy[j]] = v
+		}
+		group := SummaryGroup{Values: values}
+		key := group.Key(opts.GroupBy)
+		if groups[key] == nil {
+			groups[key] = &group
+		}
+		groups[key].Totals.add(r)
+		groupRecos[key] = append(groupRecos[key], r)
+	}
+
+	for key, group := range groups {
+		group.TopContributors = topContributors(groupRecos[key], opts.TopN)
+		summary.Groups = append(summary.Groups, *group)
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		si, sj := summary.Groups[i].Totals.Savings.Micros, summary.Groups[j].Totals.Savings.Micros
+		if si != sj {
+			return si > sj
+		}
+		return summary.Groups[i].Key(opts.GroupBy) < summary.Groups[j].Key(opts.GroupBy)
+	})
+	summary.TopContributors = topContributors(all, opts.TopN)
+	return &summary, nil
+}
@@ -0,0 +1,105 @@
+package densify
+
+import "testing"
+
+func TestSummaryGroupKey(t *testing.T) {
+	groupBy := []SummaryGroupBy{GroupByCluster, GroupByNamespace}
+	tests := []struct {
+		values map[SummaryGroupBy]string
+		want   string
+	}{
+		{map[SummaryGroupBy]string{GroupByCluster: "prod", GroupByNamespace: "shop"}, "prod/shop"},
+		{map[SummaryGroupBy]string{GroupByCluster: "a/b", GroupByNamespace: "c"}, "a%2Fb/c"},
+		{map[SummaryGroupBy]string{GroupByCluster: "a", GroupByNamespace: "b/c"}, "a/b%2Fc"},
+		{map[SummaryGroupBy]string{GroupByCluster: "", GroupByNamespace: "shop"}, "/shop"},
+	}
+	for _, tt := range tests {
+		g := SummaryGroup{Values: tt.values}
+		if got := g.Key(groupBy); got != tt.want {
+			t.Errorf("Key(%v) = %s, want %s", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeRecommendations(t *testing.T) {
+	money := func(s string) Money {
+		m, _ := ParseMoney(s, "", PeriodMonthly)
+		return m
+	}
+	recos := []DensifyRecommendation{
+		{Name: "web", AccountId: "111", Region: "us-east-1", RecommendationType: "Downsize", SavingsEstimate: money("30"), CurrentCost: money("100"), RecommendedCost: money("70")},
+		{Name: "db", AccountId: "111", Region: "us-east-1", RecommendationType: "Modernize", SavingsEstimate: money("50"), CurrentCost: money("200"), RecommendedCost: money("150")},
+		{Name: "api", AccountId: "222", Region: "eu-west-1", RecommendationType: "Downsize", SavingsEstimate: money("10")},
+		// these two would share a key if the values weren't escaped
+		{Name: "x", Cluster: "a/b", Namespace: "c", Container: "app", PodService: "x", EstimatedSavings: money("1")},
+		{Name: "y", Cluster: "a", Namespace: "b/c", Container: "app", PodService: "y", EstimatedSavings: money("2")},
+	}
+
+	summary, err := SummarizeRecommendations(&recos, &SummaryOptions{GroupBy: []SummaryGroupBy{GroupByCluster, GroupByNamespace}, TopN: 2})
+	if err != nil {
+		t.Fatalf("SummarizeRecommendations() error: %v", err)
+	}
+	if summary.Totals.Count != 5 || summary.Totals.Savings.Amount() != "93.00" || summary.Totals.CurrentCost.Amount() != "300.00" {
+		t.Errorf("Totals = %+v", summary.Totals)
+	}
+	if summary.Totals.RecommendationTypes["Downsize"] != 2 {
+		t.Errorf("RecommendationTypes = %v, want 2 Downsize", summary.Totals.RecommendationTypes)
+	}
+	// the cloud recommendations have no cluster or namespace, so they're one group
+	if len(summary.Groups) != 3 {
+		t.Fatalf("got %d groups, want 3: %+v", len(summary.Groups), summary.Groups)
+	}
+	wantKeys := []string{"/", "a/b%2Fc", "a%2Fb/c"}
+	for i := 0; i < len(wantKeys); i++ {
+		if got := summary.Groups[i].Key(summary.GroupBy); got != wantKeys[i] {
+			t.Errorf("Groups[%d].Key() = %s, want %s", i, got, wantKeys[i])
+		}
+	}
+	if len(summary.TopContributors) != 2 || summary.TopContributors[0].Name != "db" || summary.TopContributors[1].Name != "web" {
+		t.Errorf("TopContributors = %+v, want db then web", summary.TopContributors)
+	}
+
+	invalid := &SummaryOptions{GroupBy: []SummaryGroupBy{GroupByRegion, "owner"}}
+	empty := []DensifyRecommendation{}
+	tests := []struct {
+		name  string
+		recos *[]DensifyRecommendation
+	}{
+		{"recommendations", &recos},
+		{"empty", &empty},
+		{"nil", nil},
+	}
+	for _, tt := range tests {
+		if _, err := SummarizeRecommendations(tt.recos, invalid); err == nil {
+			t.Errorf("%s: SummarizeRecommendations() with an invalid group by didn't return an error", tt.name)
+		}
+	}
+	if summary, err := SummarizeRecommendations(nil, &SummaryOptions{GroupBy: []SummaryGroupBy{GroupByRegion}}); err != nil || summary.Totals.Count != 0 {
+		t.Errorf("SummarizeRecommendations(nil) = %+v, %v; want empty totals", summary, err)
+	}
+}
+
+func TestSummarizeRecommendationsCurrencies(t *testing.T) {
+	money := func(amount string, currency string) Money {
+		m, _ := ParseMoney(amount, currency, PeriodMonthly)
+		return m
+	}
+	recos := []DensifyRecommendation{
+		{Name: "web", Region: "us-east-1", SavingsEstimate: money("30", "USD")},
+		{Name: "api", Region: "us-east-1", SavingsEstimate: money("10", "USD")},
+		{Name: "db", Region: "eu-west-1", SavingsEstimate: money("20", "EUR")},
+	}
+	summary, err := SummarizeRecommendations(&recos, &SummaryOptions{GroupBy: []SummaryGroupBy{GroupByRegion}})
+	if err != nil {
+		t.Fatalf("SummarizeRecommendations() error: %v", err)
+	}
+	// the overall savings are in two currencies, so they're marked as mixed rather than reported as 60 USD
+	if !summary.Totals.Savings.IsMixedCurrency() {
+		t.Errorf("Totals.Savings = %s, want a mixed currency", summary.Totals.Savings)
+	}
+	for i := 0; i < len(summary.Groups); i++ {
+		if savings := summary.Groups[i].Totals.Savings; savings.IsMixedCurrency() {
+			t.Errorf("group %s savings = %s, want one currency", summary.Groups[i].Key(summary.GroupBy), savings)
+		}
+	}
+}
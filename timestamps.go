@@ -0,0 +1,86 @@
+package densify
+
+import "time"
+
+// A timestamp in epoch milliseconds, as returned by the Densify API; zero means not set. The JSON form stays a plain number.
+type EpochMillis int64
+
+const day = 24 * time.Hour
+
+// Create an epoch milliseconds timestamp from a time; the zero time returns zero (not set).
+func NewEpochMillis(t time.Time) EpochMillis {
+	if t.IsZero() {
+		return 0
+	}
+	return EpochMillis(t.UnixMilli())
+}
+
+// returns true if the timestamp is not set
+func (t EpochMillis) IsZero() bool {
+	return t == 0
+}
+
+// returns the timestamp as a time; zero returns the zero time
+func (t EpochMillis) Time() time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(t))
+}
+
+// returns the time elapsed since the timestamp; zero if it's not set
+func (t EpochMillis) Age() time.Duration {
+	if t.IsZero() {
+		return 0
+	}
+	return time.Since(t.Time())
+}
+
+// formats the timestamp as RFC 3339 in UTC, or an empty string if it's not set
+func (t EpochMillis) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Time().UTC().Format(time.RFC3339)
+}
+
+// returns the number of whole days in a duration
+func wholeDays(d time.Duration) int64 {
+	return int64(d / day)
+}
+
+// returns how long ago Densify first made this recommendation; zero if unknown
+func (r *DensifyRecommendation) RecommendationAge() time.Duration {
+	return r.RecommFirstSeen.Age()
+}
+
+// returns how long ago Densify last made this recommendation; zero if unknown
+func (r *DensifyRecommendation) TimeSinceLastSeen() time.Duration {
+	return r.RecommLastSeen.Age()
+}
+
+// returns the number of whole days since the entity was last audited (data collected); -1 if it was never audited
+func (r *DensifyRecommendation) DaysSinceLastAudit() int64 {
+	if r.AuditInfo.DataCollection.DateLastAudited.IsZero() {
+		return -1
+	}
+	return wholeDays(r.AuditInfo.DataCollection.DateLastAudited.Age())
+}
+
+// returns the length of the audit (data collection) history; zero if unknown
+func (a *AuditInfoDataCollection) AuditedDuration() time.Duration {
+	if a.DateFirstAudited.IsZero() || a.DateLastAudited.IsZero() {
+		return 0
+	}
+	return a.DateLastAudited.Time().Sub(a.DateFirstAudited.Time())
+}
+
+// returns the time the API token expires
+func (c *DensifyClient) TokenExpiry() time.Time {
+	return c.ApiTokenExpiry.Time()
+}
+
+// returns how long until the API token expires; negative if it has already expired
+func (c *DensifyClient) TokenExpiresIn() time.Duration {
+	return time.Until(c.ApiTokenExpiry.Time())
+}
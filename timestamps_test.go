@@ -0,0 +1,110 @@
+package densify
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestEpochMillisZero(t *testing.T) {
+	var zero EpochMillis
+	if !zero.IsZero() {
+		t.Errorf("IsZero() = false, want true")
+	}
+	if !zero.Time().IsZero() {
+		t.Errorf("Time() = %v, want the zero time", zero.Time())
+	}
+	if zero.Age() != 0 {
+		t.Errorf("Age() = %v, want 0", zero.Age())
+	}
+	if zero.String() != "" {
+		t.Errorf("String() = %q, want empty", zero.String())
+	}
+	if got := NewEpochMillis(time.Time{}); got != 0 {
+		t.Errorf("NewEpochMillis(zero time) = %d, want 0", got)
+	}
+}
+
+func TestEpochMillisTime(t *testing.T) {
+	tests := []struct {
+		millis EpochMillis
+		want   time.Time
+		str    string
+	}{
+		{1, time.Date(1970, 1, 1, 0, 0, 0, 1000000, time.UTC), "1970-01-01T00:00:00Z"},
+		{1714521600000, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), "2024-05-01T00:00:00Z"},
+		{1714521600999, time.Date(2024, 5, 1, 0, 0, 0, 999000000, time.UTC), "2024-05-01T00:00:00Z"},
+		{-86400000, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), "1969-12-31T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		if got := tt.millis.Time(); !got.Equal(tt.want) {
+			t.Errorf("EpochMillis(%d).Time() = %v, want %v", tt.millis, got, tt.want)
+		}
+		if got := tt.millis.String(); got != tt.str {
+			t.Errorf("EpochMillis(%d).String() = %s, want %s", tt.millis, got, tt.str)
+		}
+		if got := NewEpochMillis(tt.want); got != tt.millis {
+			t.Errorf("NewEpochMillis(%v) = %d, want %d", tt.want, got, tt.millis)
+		}
+	}
+}
+
+func TestEpochMillisAge(t *testing.T) {
+	ts := NewEpochMillis(time.Now().Add(-3*day - time.Hour))
+	if days := wholeDays(ts.Age()); days != 3 {
+		t.Errorf("wholeDays(Age()) = %d, want 3", days)
+	}
+
+	var r DensifyRecommendation
+	if r.DaysSinceLastAudit() != -1 {
+		t.Errorf("DaysSinceLastAudit() = %d, want -1", r.DaysSinceLastAudit())
+	}
+	r.AuditInfo.DataCollection.DateLastAudited = ts
+	if r.DaysSinceLastAudit() != 3 {
+		t.Errorf("DaysSinceLastAudit() = %d, want 3", r.DaysSinceLastAudit())
+	}
+	if r.AuditInfo.DataCollection.AuditedDuration() != 0 {
+		t.Errorf("AuditedDuration() without a first audit = %v, want 0", r.AuditInfo.DataCollection.AuditedDuration())
+	}
+	r.AuditInfo.DataCollection.DateFirstAudited = ts - EpochMillis(2*day/time.Millisecond)
+	if got := r.AuditInfo.DataCollection.AuditedDuration(); got != 2*day {
+		t.Errorf("AuditedDuration() = %v, want %v", got, 2*day)
+	}
+}
+
+func TestEpochMillisJSON(t *testing.T) {
+	type timestamps struct {
+		First EpochMillis `json:"first"`
+		Last  EpochMillis `json:"last,omitempty"`
+	}
+	tests := []struct {
+		name string
+		json string
+		want timestamps
+	}{
+		{"set", `{"first":1714521600000,"last":1714608000000}`, timestamps{1714521600000, 1714608000000}},
+		{"not set", `{"first":0}`, timestamps{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got timestamps
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+			b, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal() error: %v", err)
+			}
+			if string(b) != tt.json {
+				t.Errorf("Marshal() = %s, want %s", b, tt.json)
+			}
+		})
+	}
+	var bad timestamps
+	if err := json.Unmarshal([]byte(`{"first":"2024-05-01"}`), &bad); err == nil {
+		t.Errorf("Unmarshal() of a string timestamp didn't return an error")
+	}
+}
@@ -2,6 +2,7 @@ package densify
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -40,14 +41,17 @@ func (l *UniqueList) CsvStrWithNewLine() string {
 	return strings.ReplaceAll(l.CsvStr(), ", ", ",\n")
 }
 
-// func (l *UniqueList) Length() int {
-// 	return len(l.strList)
-// }
-
-// func (l *UniqueList) List() *[]string {
-// 	var ls []string
-// 	for _, value := range l.strList {
-// 		ls = append(ls, value)
-// 	}
-// 	return &ls
-// }
+// returns the number of unique values in the list
+func (l *UniqueList) Length() int {
+	return len(l.strList)
+}
+
+// returns the unique values as a sorted slice
+func (l *UniqueList) List() []string {
+	ls := make([]string, 0, len(l.strList))
+	for key := range l.strList {
+		ls = append(ls, key)
+	}
+	sort.Strings(ls)
+	return ls
+}
@@ -0,0 +1,216 @@
+package densify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The JSON fields returned by the Densify API that this library doesn't have a struct field for (yet). They are kept when decoding and
+// written back out when the model is encoded again, so they survive re-serialization.
+type UnknownFields struct {
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+// returns the names of the unknown fields, sorted
+func (u *UnknownFields) UnknownFieldNames() []string {
+	names := make([]string, 0, len(u.Unknown))
+	for name := range u.Unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Decode an unknown field into v; returns false if the field wasn't returned by the API.
+func (u *UnknownFields) GetUnknownField(name string, v interface{}) (bool, error) {
+	raw, ok := u.Unknown[name]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// the lowercase JSON names of a struct type's fields, cached per type; encoding/json matches names case insensitively, so this does too
+var knownJSONFields sync.Map // reflect.Type > map[string]bool
+
+func getKnownJSONFields(t reflect.Type) map[string]bool {
+	if fields, ok := knownJSONFields.Load(t); ok {
+		return fields.(map[string]bool)
+	}
+	fields := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		// embedded structs without a name have their fields promoted
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for embedded := range getKnownJSONFields(f.Type) {
+				fields[embedded] = true
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = true
+	}
+	knownJSONFields.Store(t, fields)
+	return fields
+}
+
+// decodes data into v (a pointer to a struct without its own UnmarshalJSON) and returns the fields that v doesn't have, or nil if there are none
+func unmarshalWithUnknownFields(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil || all == nil {
+		// not an object (ex. null), so there are no fields
+		return nil, nil
+	}
+	known := getKnownJSONFields(reflect.TypeOf(v).Elem())
+	var unknown map[string]json.RawMessage
+	for name, raw := range all {
+		if known[strings.ToLower(name)] {
+			continue
+		}
+		if unknown == nil {
+			unknown = map[string]json.RawMessage{}
+		}
+		unknown[name] = raw
+	}
+	return unknown, nil
+}
+
+// encodes v (a struct without its own MarshalJSON) and adds the unknown fields after the known ones, sorted by name
+func marshalWithUnknownFields(v interface{}, unknown map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(unknown) == 0 {
+		return data, err
+	}
+	known := getKnownJSONFields(reflect.TypeOf(v))
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		// a known field always wins over an unknown one with the same name
+		if !known[strings.ToLower(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1]) // drop the closing brace
+	first := len(data) == 2       // "{}"
+	for i := 0; i < len(names); i++ {
+		key, _ := json.Marshal(names[i])
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(unknown[names[i]])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// returned in strict decoding mode when the Densify API returns fields that this library doesn't know about
+type UnknownFieldsError struct {
+	Fields []string // the unknown fields as paths, ex. "[].auditInfo.dataCollection.newField", sorted
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("the Densify API returned %d unknown field(s): %s", len(e.Fields), strings.Join(e.Fields, ", "))
+}
+
+// returns an UnknownFieldsError if any of the decoded models in v have unknown fields; slices are written as [] in the paths so that each
+// unknown field is only reported once
+func checkUnknownFields(v interface{}) error {
+	var found UniqueList
+	found.Initialize()
+	collectUnknownFields(reflect.ValueOf(v), "", &found)
+	if found.Length() == 0 {
+		return nil
+	}
+	return &UnknownFieldsError{Fields: found.List()}
+}
+
+var unknownFieldsType = reflect.TypeOf(UnknownFields{})
+
+func collectUnknownFields(v reflect.Value, path string, found *UniqueList) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			collectUnknownFields(v.Elem(), path, found)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectUnknownFields(v.Index(i), path+"[]", found)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if f.Type == unknownFieldsType {
+				u := v.Field(i).Interface().(UnknownFields)
+				for name := range u.Unknown {
+					found.Add(strings.TrimPrefix(path+"."+name, "."))
+				}
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			collectUnknownFields(v.Field(i), path+"."+name, found)
+		}
+	}
+}
+
+// A list of strings that decodes from either a JSON string or an array of strings, since the Densify API returns both forms for some fields.
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*l = nil
+		if s != "" {
+			*l = StringList{s}
+		}
+		return nil
+	}
+	var ls []string
+	if err := json.Unmarshal(data, &ls); err != nil {
+		return err
+	}
+	*l = ls
+	return nil
+}
+
+// returns the values separated by semicolons
+func (l StringList) String() string {
+	return strings.Join(l, "; ")
+}
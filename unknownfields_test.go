@@ -0,0 +1,103 @@
+package densify
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+	in := `{"entityId":"e1","name":"web","newField":{"a":1},"auditInfo":{"dataCollection":{"auditCount":3,"futureCount":7}}}`
+	var r DensifyRecommendation
+	if err := json.Unmarshal([]byte(in), &r); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if r.EntityId != "e1" || r.AuditInfo.DataCollection.AuditCount != 3 {
+		t.Errorf("known fields not decoded: %+v", r)
+	}
+	if got := r.UnknownFieldNames(); !reflect.DeepEqual(got, []string{"newField"}) {
+		t.Errorf("UnknownFieldNames() = %v, want [newField]", got)
+	}
+	var nested map[string]int
+	if found, err := r.GetUnknownField("newField", &nested); !found || err != nil || nested["a"] != 1 {
+		t.Errorf("GetUnknownField(newField) = %v, %v, %v", found, err, nested)
+	}
+	if found, _ := r.GetUnknownField("missing", &nested); found {
+		t.Errorf("GetUnknownField(missing) found a field")
+	}
+
+	out, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	var again DensifyRecommendation
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatalf("Unmarshal(Marshal()) error: %v", err)
+	}
+	if string(again.Unknown["newField"]) != `{"a":1}` || string(again.AuditInfo.DataCollection.Unknown["futureCount"]) != `7` {
+		t.Errorf("unknown fields didn't survive re-serialization: %s", out)
+	}
+}
+
+func TestCheckUnknownFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		fields []string
+	}{
+		{"known fields only", `[{"entityId":"e1","containers":[{"container":"app"}]}]`, nil},
+		{"case insensitive names", `[{"ENTITYID":"e1","Guardrails":{"targets":[]}}]`, nil},
+		{"top level", `[{"entityId":"e1","extra":1},{"extra":2,"other":true}]`, []string{"[].extra", "[].other"}},
+		{"nested", `[{"auditInfo":{"workloadDataLast30":{"x":1}},"containers":[{"y":2}],"Guardrails":{"targets":[{"z":3}]}}]`,
+			[]string{"[].Guardrails.targets[].z", "[].auditInfo.workloadDataLast30.x", "[].containers[].y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recos []DensifyRecommendation
+			if err := json.Unmarshal([]byte(tt.in), &recos); err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			err := checkUnknownFields(recos)
+			if tt.fields == nil {
+				if err != nil {
+					t.Errorf("checkUnknownFields() = %v, want nil", err)
+				}
+				return
+			}
+			var unknownErr *UnknownFieldsError
+			if !errors.As(err, &unknownErr) {
+				t.Fatalf("checkUnknownFields() = %v, want an UnknownFieldsError", err)
+			}
+			if !reflect.DeepEqual(unknownErr.Fields, tt.fields) {
+				t.Errorf("Fields = %v, want %v", unknownErr.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestStringList(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   StringList
+		hasErr bool
+	}{
+		{`"burstable"`, StringList{"burstable"}, false},
+		{`""`, nil, false},
+		{`["a","b"]`, StringList{"a", "b"}, false},
+		{`[]`, StringList{}, false},
+		{`5`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var got StringList
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if (err != nil) != tt.hasErr {
+				t.Fatalf("Unmarshal(%s) error = %v, want error %v", tt.in, err, tt.hasErr)
+			}
+			if !tt.hasErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal(%s) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
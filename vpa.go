@@ -0,0 +1,149 @@
+package densify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// how a VerticalPodAutoscaler applies its recommendations
+type VPAUpdateMode string
+
+const (
+	VPAUpdateOff      VPAUpdateMode = "Off"      // only recommend; nothing is changed
+	VPAUpdateInitial  VPAUpdateMode = "Initial"  // only set the resources when pods are created
+	VPAUpdateRecreate VPAUpdateMode = "Recreate" // set the resources when pods are created, and evict pods to update them
+	VPAUpdateAuto     VPAUpdateMode = "Auto"
+)
+
+// options for GenerateVPA
+type VPAOptions struct {
+	UpdateMode VPAUpdateMode // defaults to Off
+	NameSuffix string        // added to the workload name to name the VPA; defaults to -vpa
+}
+
+// returns the container's VPA policy: the minimum allowed is the smaller of the recommended and current requests, and the maximum allowed
+// is the largest of the recommended and current limits (or requests, if there are no limits); nil if the container has no values
+func (c *DensifyContainerRecommendation) vpaContainerPolicy() *exportObject {
+	minCpu, _ := quantityBounds(c.RecommendedCpuRequest, c.CurrentCpuRequest)
+	_, maxCpu := quantityBounds(c.RecommendedCpuLimit, c.CurrentCpuLimit)
+	if maxCpu.IsZero() {
+		_, maxCpu = quantityBounds(c.RecommendedCpuRequest, c.CurrentCpuRequest)
+	}
+	minMem, _ := quantityBounds(c.RecommendedMemRequest, c.CurrentMemRequest)
+	_, maxMem := quantityBounds(c.RecommendedMemLimit, c.CurrentMemLimit)
+	if maxMem.IsZero() {
+		_, maxMem = quantityBounds(c.RecommendedMemRequest, c.CurrentMemRequest)
+	}
+
+	bounds := func(cpu CpuQuantity, mem MemQuantity) *exportObject {
+		o := &exportObject{}
+		if !cpu.IsZero() {
+			o.set("cpu", cpu.String())
+		}
+		if !mem.IsZero() {
+			o.set("memory", mem.String())
+		}
+		return o
+	}
+	minAllowed, maxAllowed := bounds(minCpu, minMem), bounds(maxCpu, maxMem)
+	if len(minAllowed.attributes) == 0 && len(maxAllowed.attributes) == 0 {
+		return nil
+	}
+
+	policy := &exportObject{}
+	policy.set("containerName", c.Container)
+	if len(minAllowed.attributes) > 0 {
+		policy.set("minAllowed", minAllowed)
+	}
+	if len(maxAllowed.attributes) > 0 {
+		policy.set("maxAllowed", maxAllowed)
+	}
+	policy.set("controlledResources", []interface{}{"cpu", "memory"})
+	return policy
+}
+
+// Generate an autoscaling.k8s.io/v1 VerticalPodAutoscaler for the workload, targeting its controller, with each container's minimum and
+// maximum allowed resources bounded by its recommended and current requests and limits. Bare pods can't be targeted. The options can be
+// nil.
+func (r *DensifyRecommendation) GenerateVPA(opts *VPAOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &VPAOptions{}
+	}
+	mode := opts.UpdateMode
+	if mode == "" {
+		mode = VPAUpdateOff
+	}
+	switch mode {
+	case VPAUpdateOff, VPAUpdateInitial, VPAUpdateRecreate, VPAUpdateAuto:
+	default:
+		return nil, fmt.Errorf("invalid VPA update mode '%s'; must be one of: Off, Initial, Recreate, Auto", mode)
+	}
+	suffix := opts.NameSuffix
+	if suffix == "" {
+		suffix = "-vpa"
+	}
+
+	kind, err := r.podTemplateKind()
+	if err != nil {
+		return nil, err
+	}
+	if kind.kind == "Pod" {
+		return nil, fmt.Errorf("workload '%s' is a pod; a VPA can only target a controller", r.WorkloadKey())
+	}
+	pod := r.asPod()
+	if len(pod.Containers) == 0 {
+		return nil, fmt.Errorf("workload '%s' has no containers", r.WorkloadKey())
+	}
+
+	policies := []interface{}{}
+	for i := 0; i < len(pod.Containers); i++ {
+		if policy := pod.Containers[i].vpaContainerPolicy(); policy != nil {
+			policies = append(policies, policy)
+		}
+	}
+
+	metadata := &exportObject{}
+	metadata.set("name", r.PodService+suffix)
+	metadata.set("namespace", r.Namespace)
+	targetRef := &exportObject{}
+	targetRef.set("apiVersion", kind.apiVersion)
+	targetRef.set("kind", kind.kind)
+	targetRef.set("name", r.PodService)
+	updatePolicy := &exportObject{}
+	updatePolicy.set("updateMode", string(mode))
+	resourcePolicy := &exportObject{}
+	resourcePolicy.set("containerPolicies", policies)
+	spec := &exportObject{}
+	spec.set("targetRef", targetRef)
+	spec.set("updatePolicy", updatePolicy)
+	spec.set("resourcePolicy", resourcePolicy)
+	vpa := &exportObject{}
+	vpa.set("apiVersion", "autoscaling.k8s.io/v1")
+	vpa.set("kind", "VerticalPodAutoscaler")
+	vpa.set("metadata", metadata)
+	vpa.set("spec", spec)
+
+	doc, err := formatYAML(vpa)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(doc), nil
+}
+
+// Generate a VerticalPodAutoscaler for each of the workloads (ex. from GetDensifyWorkloads or GroupWorkloads), sorted by workload, as a
+// multi-document YAML stream. Bare pods are skipped, since a VPA can't target them. The options can be nil.
+func GenerateVPAs(workloads map[WorkloadKey]*DensifyRecommendation, opts *VPAOptions) ([]byte, error) {
+	keys, recos := sortedWorkloads(workloads)
+	docs := []string{}
+	for i := 0; i < len(keys); i++ {
+		if keys[i].ControllerType == "pod" {
+			continue
+		}
+		vpa, err := recos[i].GenerateVPA(opts)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, string(vpa))
+	}
+	return []byte(strings.Join(docs, "---\n")), nil
+}
@@ -0,0 +1,125 @@
+package densify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// a VerticalPodAutoscaler, as it's decoded
+type vpaDocument struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec struct {
+		TargetRef struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Name       string `yaml:"name"`
+		} `yaml:"targetRef"`
+		UpdatePolicy struct {
+			UpdateMode string `yaml:"updateMode"`
+		} `yaml:"updatePolicy"`
+		ResourcePolicy struct {
+			ContainerPolicies []map[string]interface{} `yaml:"containerPolicies"`
+		} `yaml:"resourcePolicy"`
+	} `yaml:"spec"`
+}
+
+func TestGenerateVPA(t *testing.T) {
+	reco := DensifyRecommendation{Namespace: "shop", ControllerType: "statefulset", PodService: "db", Containers: []DensifyContainerRecommendation{
+		{Container: "db", CurrentCpuRequest: 1000, RecommendedCpuRequest: 500, CurrentCpuLimit: 2000, RecommendedCpuLimit: 1500, CurrentMemRequest: 1024, RecommendedMemRequest: 2048},
+		{Container: "empty"},
+	}}
+	out, err := reco.GenerateVPA(&VPAOptions{UpdateMode: VPAUpdateInitial, NameSuffix: "-densify"})
+	if err != nil {
+		t.Fatalf("GenerateVPA() error: %v", err)
+	}
+	var vpa vpaDocument
+	if err := yaml.Unmarshal(out, &vpa); err != nil {
+		t.Fatalf("Unmarshal() error: %v\n%s", err, out)
+	}
+	if vpa.APIVersion != "autoscaling.k8s.io/v1" || vpa.Kind != "VerticalPodAutoscaler" || vpa.Metadata.Name != "db-densify" || vpa.Metadata.Namespace != "shop" {
+		t.Errorf("VPA header = %+v", vpa)
+	}
+	if ref := vpa.Spec.TargetRef; ref.APIVersion != "apps/v1" || ref.Kind != "StatefulSet" || ref.Name != "db" {
+		t.Errorf("targetRef = %+v", ref)
+	}
+	if vpa.Spec.UpdatePolicy.UpdateMode != "Initial" {
+		t.Errorf("updateMode = %s, want Initial", vpa.Spec.UpdatePolicy.UpdateMode)
+	}
+	// the container without values has no policy; memory has no limits, so the maximum is the larger request
+	policies := vpa.Spec.ResourcePolicy.ContainerPolicies
+	if len(policies) != 1 {
+		t.Fatalf("got %d container policies, want 1: %v", len(policies), policies)
+	}
+	got, _ := json.Marshal(policies[0])
+	want := `{"containerName":"db","controlledResources":["cpu","memory"],"maxAllowed":{"cpu":"2","memory":"2Gi"},"minAllowed":{"cpu":"500m","memory":"1Gi"}}`
+	if string(got) != want {
+		t.Errorf("container policy = %s, want %s", got, want)
+	}
+}
+
+func TestGenerateVPAErrors(t *testing.T) {
+	deployment := DensifyRecommendation{Namespace: "shop", ControllerType: "deployment", PodService: "cart", Containers: []DensifyContainerRecommendation{{Container: "web"}}}
+	pod := deployment
+	pod.ControllerType = "pod"
+	noContainers := deployment
+	noContainers.Containers = nil
+	tests := []struct {
+		name string
+		reco DensifyRecommendation
+		opts *VPAOptions
+	}{
+		{"invalid update mode", deployment, &VPAOptions{UpdateMode: "auto"}},
+		{"bare pod", pod, nil},
+		{"no containers", noContainers, nil},
+		{"cloud recommendation", DensifyRecommendation{Name: "web"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out, err := tt.reco.GenerateVPA(tt.opts); err == nil {
+				t.Errorf("GenerateVPA() = %s, want an error", out)
+			}
+		})
+	}
+}
+
+func TestGenerateVPAs(t *testing.T) {
+	cart := patchTestRecommendation("deployment")
+	db := patchTestRecommendation("statefulset")
+	db.PodService = "db"
+	pod := patchTestRecommendation("pod")
+	pod.PodService = "debug"
+	out, err := GenerateVPAs(overlayTestWorkloads(db, cart, pod), nil)
+	if err != nil {
+		t.Fatalf("GenerateVPAs() error: %v", err)
+	}
+	// the bare pod is skipped and the VPAs are sorted by workload
+	names := []string{}
+	dec := yaml.NewDecoder(bytes.NewReader(out))
+	for {
+		var vpa vpaDocument
+		err := dec.Decode(&vpa)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decode() error: %v\n%s", err, out)
+		}
+		if vpa.Spec.UpdatePolicy.UpdateMode != "Off" {
+			t.Errorf("updateMode = %s, want Off", vpa.Spec.UpdatePolicy.UpdateMode)
+		}
+		names = append(names, vpa.Metadata.Name)
+	}
+	if len(names) != 2 || names[0] != "cart-vpa" || names[1] != "db-vpa" {
+		t.Errorf("VPAs = %v, want cart-vpa, db-vpa", names)
+	}
+}
@@ -0,0 +1,185 @@
+package densify
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// The identity of a kubernetes workload: cluster/namespace/controllerType/name, ex. "prod/payments/deployment/api". Keys are normalized
+// (trimmed and lowercased) so they can be compared with == and used as map keys.
+type WorkloadKey struct {
+	Cluster        string
+	Namespace      string
+	ControllerType string
+	Name           string // the pod/service name
+}
+
+// The identity of a container within a kubernetes workload: cluster/namespace/controllerType/name/container.
+type ContainerKey struct {
+	WorkloadKey
+	Container string
+}
+
+func normalizeKeyPart(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// Create a normalized workload key.
+func NewWorkloadKey(cluster string, namespace string, controllerType string, name string) WorkloadKey {
+	return WorkloadKey{
+		Cluster:        normalizeKeyPart(cluster),
+		Namespace:      normalizeKeyPart(namespace),
+		ControllerType: normalizeKeyPart(controllerType),
+		Name:           normalizeKeyPart(name),
+	}
+}
+
+// Create a normalized container key.
+func NewContainerKey(cluster string, namespace string, controllerType string, name string, container string) ContainerKey {
+	return NewWorkloadKey(cluster, namespace, controllerType, name).ContainerKey(container)
+}
+
+// returns the key of a container in the workload
+func (k WorkloadKey) ContainerKey(container string) ContainerKey {
+	return ContainerKey{WorkloadKey: k.Normalize(), Container: normalizeKeyPart(container)}
+}
+
+// returns the key with all the parts trimmed and lowercased
+func (k WorkloadKey) Normalize() WorkloadKey {
+	return NewWorkloadKey(k.Cluster, k.Namespace, k.ControllerType, k.Name)
+}
+
+// returns the key with all the parts trimmed and lowercased
+func (k ContainerKey) Normalize() ContainerKey {
+	return k.WorkloadKey.ContainerKey(k.Container)
+}
+
+// returns true if both keys are the same workload, ignoring the cluster (ex. when the query's cluster is only part of the analysis name)
+func (k WorkloadKey) sameWorkloadInCluster(o WorkloadKey) bool {
+	k.Cluster, o.Cluster = "", ""
+	return k.Normalize() == o.Normalize()
+}
+
+// formats the parts separated by slashes; slashes within a part are escaped
+func formatKeyParts(parts ...string) string {
+	for i := 0; i < len(parts); i++ {
+		parts[i] = url.PathEscape(parts[i])
+	}
+	return strings.Join(parts, "/")
+}
+
+// parses parts separated by slashes; empty parts are only allowed with allowEmpty (ex. a key without a cluster written by MarshalText)
+func parseKeyParts(s string, count int, format string, allowEmpty bool) ([]string, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != count {
+		return nil, fmt.Errorf("invalid key '%s'; must be %s", s, format)
+	}
+	for i := 0; i < len(parts); i++ {
+		part, err := url.PathUnescape(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid key '%s': %v", s, err)
+		}
+		if !allowEmpty && strings.TrimSpace(part) == "" {
+			return nil, fmt.Errorf("invalid key '%s'; must be %s with no empty parts", s, format)
+		}
+		parts[i] = part
+	}
+	return parts, nil
+}
+
+// formats the key as cluster/namespace/controllerType/name
+func (k WorkloadKey) String() string {
+	return formatKeyParts(k.Cluster, k.Namespace, k.ControllerType, k.Name)
+}
+
+// formats the key as cluster/namespace/controllerType/name/container
+func (k ContainerKey) String() string {
+	return formatKeyParts(k.Cluster, k.Namespace, k.ControllerType, k.Name, k.Container)
+}
+
+// Parse a workload key formatted as cluster/namespace/controllerType/name; the result is normalized.
+func ParseWorkloadKey(s string) (WorkloadKey, error) {
+	return parseWorkloadKey(s, false)
+}
+
+func parseWorkloadKey(s string, allowEmpty bool) (WorkloadKey, error) {
+	parts, err := parseKeyParts(s, 4, "cluster/namespace/controllerType/name", allowEmpty)
+	if err != nil {
+		return WorkloadKey{}, err
+	}
+	if !isValidControllerType(parts[2]) {
+		return WorkloadKey{}, fmt.Errorf("invalid key '%s'; controller type must be valid: pod, deployment, replicaset, daemonset, statefulset, cronjob, job", s)
+	}
+	return NewWorkloadKey(parts[0], parts[1], parts[2], parts[3]), nil
+}
+
+// Parse a container key formatted as cluster/namespace/controllerType/name/container; the result is normalized.
+func ParseContainerKey(s string) (ContainerKey, error) {
+	return parseContainerKey(s, false)
+}
+
+func parseContainerKey(s string, allowEmpty bool) (ContainerKey, error) {
+	parts, err := parseKeyParts(s, 5, "cluster/namespace/controllerType/name/container", allowEmpty)
+	if err != nil {
+		return ContainerKey{}, err
+	}
+	if !isValidControllerType(parts[2]) {
+		return ContainerKey{}, fmt.Errorf("invalid key '%s'; controller type must be valid: pod, deployment, replicaset, daemonset, statefulset, cronjob, job", s)
+	}
+	return NewContainerKey(parts[0], parts[1], parts[2], parts[3], parts[4]), nil
+}
+
+// Keys are written as their string form, so they can be used as JSON map keys. Recommendations can have empty parts (ex. no cluster or
+// controller type), so unlike ParseWorkloadKey and ParseContainerKey, UnmarshalText accepts empty parts and any key MarshalText writes
+// decodes back to the same key.
+func (k WorkloadKey) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *WorkloadKey) UnmarshalText(text []byte) error {
+	parsed, err := parseWorkloadKey(string(text), true)
+	if err != nil {
+		return err
+	}
+	*k = parsed
+	return nil
+}
+
+func (k ContainerKey) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *ContainerKey) UnmarshalText(text []byte) error {
+	parsed, err := parseContainerKey(string(text), true)
+	if err != nil {
+		return err
+	}
+	*k = parsed
+	return nil
+}
+
+// returns true if the recommendation is for a kubernetes container or workload
+func (r *DensifyRecommendation) isKubernetes() bool {
+	return r.Namespace != "" || r.PodService != ""
+}
+
+// returns the normalized key of the kubernetes workload the recommendation is for
+func (r *DensifyRecommendation) WorkloadKey() WorkloadKey {
+	return NewWorkloadKey(r.Cluster, r.Namespace, r.ControllerType, r.PodService)
+}
+
+// returns the normalized key of the kubernetes container the recommendation is for
+func (r *DensifyRecommendation) ContainerKey() ContainerKey {
+	return r.WorkloadKey().ContainerKey(r.Container)
+}
+
+// returns the normalized key of the container
+func (c *DensifyContainerRecommendation) ContainerKey() ContainerKey {
+	return NewContainerKey(c.Cluster, c.Namespace, c.ControllerType, c.PodService, c.Container)
+}
+
+// returns the normalized key of the workload the query is for
+func (q *DensifyAPIQuery) WorkloadKey() WorkloadKey {
+	return NewWorkloadKey(q.K8sCluster, q.K8sNamespace, q.K8sControllerType, q.K8sPodName)
+}
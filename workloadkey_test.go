@@ -0,0 +1,136 @@
+package densify
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseWorkloadKey(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   WorkloadKey
+		hasErr bool
+	}{
+		{"prod/payments/deployment/api", WorkloadKey{"prod", "payments", "deployment", "api"}, false},
+		{" Prod /Payments/Deployment/API", WorkloadKey{"prod", "payments", "deployment", "api"}, false},
+		{"us%2Feast/payments/deployment/api", WorkloadKey{"us/east", "payments", "deployment", "api"}, false},
+		{"prod/payments/deployment", WorkloadKey{}, true},
+		{"prod/payments/deployment/api/extra", WorkloadKey{}, true},
+		{"/payments/deployment/api", WorkloadKey{}, true},
+		{"prod/payments/unknown/api", WorkloadKey{}, true},
+		{"prod/payments/deployment/%zz", WorkloadKey{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseWorkloadKey(tt.in)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("ParseWorkloadKey(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWorkloadKey(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseWorkloadKey(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+			if back, err := ParseWorkloadKey(got.String()); err != nil || back != got {
+				t.Errorf("ParseWorkloadKey(%s) = %+v, %v; want %+v", got.String(), back, err, got)
+			}
+		})
+	}
+}
+
+func TestParseContainerKey(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   ContainerKey
+		hasErr bool
+	}{
+		{"prod/payments/deployment/api/app", NewContainerKey("prod", "payments", "deployment", "api", "app"), false},
+		{"prod/payments/deployment/api/side%2Fcar", NewContainerKey("prod", "payments", "deployment", "api", "side/car"), false},
+		{"prod/payments/deployment/api", ContainerKey{}, true},
+		{"prod/payments/deployment/api/", ContainerKey{}, true},
+		{"prod/payments/unknown/api/app", ContainerKey{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseContainerKey(tt.in)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("ParseContainerKey(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseContainerKey(%q) error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseContainerKey(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkloadKeyText(t *testing.T) {
+	tests := []struct {
+		name string
+		key  WorkloadKey
+		text string
+	}{
+		{"all parts", NewWorkloadKey("prod", "payments", "deployment", "api"), "prod/payments/deployment/api"},
+		{"no cluster", NewWorkloadKey("", "payments", "deployment", "api"), "/payments/deployment/api"},
+		{"no controller type", NewWorkloadKey("prod", "payments", "", "api"), "prod/payments//api"},
+		{"escaped", NewWorkloadKey("a/b", "c d", "statefulset", "e%f"), "a%2Fb/c%20d/statefulset/e%25f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := tt.key.MarshalText()
+			if err != nil || string(text) != tt.text {
+				t.Fatalf("MarshalText() = %s, %v; want %s", text, err, tt.text)
+			}
+			var got WorkloadKey
+			if err := got.UnmarshalText(text); err != nil || got != tt.key {
+				t.Errorf("UnmarshalText(%s) = %+v, %v; want %+v", text, got, err, tt.key)
+			}
+			container := tt.key.ContainerKey("app")
+			text, _ = container.MarshalText()
+			var gotContainer ContainerKey
+			if err := gotContainer.UnmarshalText(text); err != nil || gotContainer != container {
+				t.Errorf("UnmarshalText(%s) = %+v, %v; want %+v", text, gotContainer, err, container)
+			}
+		})
+	}
+	var k WorkloadKey
+	if err := k.UnmarshalText([]byte("prod/payments/api")); err == nil {
+		t.Errorf("UnmarshalText() with 3 parts didn't return an error")
+	}
+}
+
+func TestWorkloadMapJSON(t *testing.T) {
+	recos := []DensifyRecommendation{
+		{Cluster: "prod", Namespace: "payments", ControllerType: "deployment", PodService: "api", Container: "app"},
+		{Namespace: "payments", PodService: "worker", Container: "app"}, // no cluster or controller type
+	}
+	workloads, err := GroupWorkloads(&recos, nil)
+	if err != nil {
+		t.Fatalf("GroupWorkloads() error: %v", err)
+	}
+	out, err := json.Marshal(workloads)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	var back map[WorkloadKey]*DensifyRecommendation
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatalf("Unmarshal(%s) error: %v", out, err)
+	}
+	if len(back) != len(workloads) {
+		t.Fatalf("got %d workloads, want %d", len(back), len(workloads))
+	}
+	for key := range workloads {
+		if _, ok := back[key]; !ok {
+			t.Errorf("workload %s didn't decode back", key)
+		}
+	}
+}
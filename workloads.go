@@ -0,0 +1,171 @@
+package densify
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// optional filters used when pulling all the kubernetes workloads in a cluster or namespace
+type DensifyWorkloadFilter struct {
+	NamespaceGlob   string   // shell-style pattern matched against the namespace, ex. "team-*"; empty matches all namespaces
+	ControllerTypes []string // only return these controller types, ex. deployment, statefulset; empty returns all controller types
+}
+
+// check the filter values are valid before pulling any recommendations
+func (f *DensifyWorkloadFilter) validate() error {
+	if f == nil {
+		return nil
+	}
+	if _, err := path.Match(strings.ToLower(f.NamespaceGlob), ""); err != nil {
+		return fmt.Errorf("invalid namespace glob '%s': %v", f.NamespaceGlob, err)
+	}
+	for i := 0; i < len(f.ControllerTypes); i++ {
+		if f.ControllerTypes[i] == "" || !isValidControllerType(f.ControllerTypes[i]) {
+			return fmt.Errorf("filter controller type must be valid: pod, deployment, replicaset, daemonset, statefulset, cronjob, job")
+		}
+	}
+	return nil
+}
+
+// returns true if the container recommendation passes the filter
+func (f *DensifyWorkloadFilter) matches(reco *DensifyRecommendation) bool {
+	if f == nil {
+		return true
+	}
+	if f.NamespaceGlob != "" {
+		// the pattern was already validated, so there's no error to check here
+		if ok, _ := path.Match(strings.ToLower(f.NamespaceGlob), strings.ToLower(reco.Namespace)); !ok {
+			return false
+		}
+	}
+	if len(f.ControllerTypes) > 0 {
+		found := false
+		for i := 0; i < len(f.ControllerTypes); i++ {
+			if strings.EqualFold(f.ControllerTypes[i], reco.ControllerType) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Pull all the kubernetes recommendations for the queried cluster (and namespace, if one is set in the query) and group the containers into
+// pod-level (workload) recommendations, keyed by workload (cluster/namespace/controllerType/podService). The filter is optional and can be nil.
+// As with GetDensifyRecommendation, the query's fallback values and maturity policy are applied to each workload's containers.
+func (c *DensifyClient) GetDensifyWorkloads(filter *DensifyWorkloadFilter) (map[WorkloadKey]*DensifyRecommendation, error) {
+	// make sure a query has been defined
+	if c.Query == nil {
+		return nil, fmt.Errorf("you must specify a query first")
+	}
+	if !c.Query.isKubernetesRequest() {
+		return nil, fmt.Errorf("workloads can only be pulled for kubernetes queries")
+	}
+	err := filter.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	recos, err := c.GetDensifyRecommendations()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := normalizeKeyPart(c.Query.K8sNamespace)
+	workloads := groupWorkloads(recos, func(reco *DensifyRecommendation) bool {
+		// only keep the namespace from the query, if one was provided
+		if namespace != "" && reco.WorkloadKey().Namespace != namespace {
+			return false
+		}
+		return filter.matches(reco)
+	})
+	// same as GetDensifyRecommendation, so the workloads' effective resources use the query's fallback values and maturity policy
+	for _, workload := range workloads {
+		c.Query.setContainerFallbacks(workload)
+		c.Query.applyMaturityPolicy(workload)
+	}
+	return workloads, nil
+}
+
+// Group (container-level) recommendations, ex. from GetDensifyRecommendations, into pod-level (workload) recommendations, keyed by workload.
+// The filter is optional and can be nil.
+func GroupWorkloads(recos *[]DensifyRecommendation, filter *DensifyWorkloadFilter) (map[WorkloadKey]*DensifyRecommendation, error) {
+	err := filter.validate()
+	if err != nil {
+		return nil, err
+	}
+	return groupWorkloads(recos, filter.matches), nil
+}
+
+// groups the kubernetes recommendations that pass keep into workloads
+func groupWorkloads(recos *[]DensifyRecommendation, keep func(reco *DensifyRecommendation) bool) map[WorkloadKey]*DensifyRecommendation {
+	workloads := map[WorkloadKey]*DensifyRecommendation{}
+	for i := 0; recos != nil && i < len(*recos); i++ {
+		reco := &(*recos)[i]
+		if !reco.isKubernetes() || !keep(reco) {
+			continue
+		}
+		key := reco.WorkloadKey()
+		if workloads[key] == nil {
+			workloads[key] = &DensifyRecommendation{}
+		}
+		workloads[key].addContainerToWorkload(reco)
+	}
+	return workloads
+}
+
+// returns the workloads sorted by key
+func sortedWorkloads(workloads map[WorkloadKey]*DensifyRecommendation) ([]WorkloadKey, []*DensifyRecommendation) {
+	keys := make([]WorkloadKey, 0, len(workloads))
+	for key := range workloads {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	recos := make([]*DensifyRecommendation, len(keys))
+	for i := 0; i < len(keys); i++ {
+		recos[i] = workloads[keys[i]]
+	}
+	return keys, recos
+}
+
+// returned when the query doesn't have a controller type and the pod name matches workloads with more than one controller type
+type AmbiguousControllerTypeError struct {
+	Namespace       string
+	PodName         string
+	ControllerTypes []string // the candidate controller types, sorted
+}
+
+func (e *AmbiguousControllerTypeError) Error() string {
+	return fmt.Sprintf("pod (%s) in namespace (%s) matches more than one controller type: %s; set the controller type in the query", e.PodName, e.Namespace, strings.Join(e.ControllerTypes, ", "))
+}
+
+// find the controller type of the workload matching the query's namespace and pod name
+func (q *DensifyAPIQuery) resolveControllerType(recos *[]DensifyRecommendation) (string, error) {
+	var candidates UniqueList
+	candidates.Initialize()
+	queryKey := q.WorkloadKey()
+	for i := 0; i < len(*recos); i++ {
+		key := (*recos)[i].WorkloadKey()
+		if key.Namespace == queryKey.Namespace && key.Name == queryKey.Name {
+			candidates.Add(key.ControllerType)
+		}
+	}
+	switch candidates.Length() {
+	case 0:
+		return "", fmt.Errorf(`could not find a Densify recommendation for pod (%s) in namespace (%s)`, q.K8sPodName, q.K8sNamespace)
+	case 1:
+		return candidates.List()[0], nil
+	}
+	return "", &AmbiguousControllerTypeError{
+		Namespace:       q.K8sNamespace,
+		PodName:         q.K8sPodName,
+		ControllerTypes: candidates.List(),
+	}
+}
@@ -0,0 +1,320 @@
+package densify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// container-level recommendations in two clusters, as the Densify API returns them
+func workloadsTestRecommendations() []DensifyRecommendation {
+	return []DensifyRecommendation{
+		{Cluster: "prod", Namespace: "shop", ControllerType: "Deployment", PodService: "cart", Container: "web", Name: "web", CurrentCpuRequest: 500},
+		{Cluster: "prod", Namespace: "shop", ControllerType: "deployment", PodService: "cart", Container: "sidecar", Name: "sidecar", CurrentCpuRequest: 100},
+		{Cluster: "prod", Namespace: "team-a", ControllerType: "statefulset", PodService: "db", Container: "db", Name: "db"},
+		{Cluster: "prod", Namespace: "team-b", ControllerType: "cronjob", PodService: "report", Container: "job", Name: "job"},
+		{Cluster: "dev", Namespace: "shop", ControllerType: "deployment", PodService: "cart", Container: "web", Name: "web"},
+		{Name: "i-1", CurrentType: "m5.large"}, // a cloud recommendation, which isn't a workload
+	}
+}
+
+// returns the workload keys, sorted
+func workloadKeys(workloads map[WorkloadKey]*DensifyRecommendation) string {
+	keys, _ := sortedWorkloads(workloads)
+	s := make([]string, len(keys))
+	for i := 0; i < len(keys); i++ {
+		s[i] = keys[i].String()
+	}
+	return strings.Join(s, ",")
+}
+
+func TestDensifyWorkloadFilterValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *DensifyWorkloadFilter
+		hasErr bool
+	}{
+		{"nil", nil, false},
+		{"empty", &DensifyWorkloadFilter{}, false},
+		{"glob and controller types", &DensifyWorkloadFilter{NamespaceGlob: "team-*", ControllerTypes: []string{"Deployment", "cronjob"}}, false},
+		{"invalid glob", &DensifyWorkloadFilter{NamespaceGlob: "team-["}, true},
+		{"empty controller type", &DensifyWorkloadFilter{ControllerTypes: []string{""}}, true},
+		{"invalid controller type", &DensifyWorkloadFilter{ControllerTypes: []string{"rollout"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.validate(); (err != nil) != tt.hasErr {
+				t.Errorf("validate() error = %v, want error %v", err, tt.hasErr)
+			}
+		})
+	}
+}
+
+func TestDensifyWorkloadFilterMatches(t *testing.T) {
+	reco := &DensifyRecommendation{Namespace: "Team-A", ControllerType: "StatefulSet"}
+	tests := []struct {
+		name   string
+		filter *DensifyWorkloadFilter
+		want   bool
+	}{
+		{"nil", nil, true},
+		{"empty", &DensifyWorkloadFilter{}, true},
+		{"namespace glob", &DensifyWorkloadFilter{NamespaceGlob: "team-*"}, true},
+		{"namespace glob case", &DensifyWorkloadFilter{NamespaceGlob: "TEAM-?"}, true},
+		{"other namespace", &DensifyWorkloadFilter{NamespaceGlob: "shop"}, false},
+		{"controller type", &DensifyWorkloadFilter{ControllerTypes: []string{"deployment", "statefulset"}}, true},
+		{"other controller type", &DensifyWorkloadFilter{ControllerTypes: []string{"deployment"}}, false},
+		{"both", &DensifyWorkloadFilter{NamespaceGlob: "team-*", ControllerTypes: []string{"deployment"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(reco); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupWorkloads(t *testing.T) {
+	recos := workloadsTestRecommendations()
+	tests := []struct {
+		name   string
+		filter *DensifyWorkloadFilter
+		want   string
+		hasErr bool
+	}{
+		{"all", nil, "dev/shop/deployment/cart,prod/shop/deployment/cart,prod/team-a/statefulset/db,prod/team-b/cronjob/report", false},
+		{"namespace glob", &DensifyWorkloadFilter{NamespaceGlob: "team-*"}, "prod/team-a/statefulset/db,prod/team-b/cronjob/report", false},
+		{"controller types", &DensifyWorkloadFilter{ControllerTypes: []string{"deployment"}}, "dev/shop/deployment/cart,prod/shop/deployment/cart", false},
+		{"nothing matches", &DensifyWorkloadFilter{NamespaceGlob: "kube-*"}, "", false},
+		{"invalid filter", &DensifyWorkloadFilter{ControllerTypes: []string{"rollout"}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloads, err := GroupWorkloads(&recos, tt.filter)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("GroupWorkloads() = %v, want an error", workloadKeys(workloads))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GroupWorkloads() error: %v", err)
+			}
+			if got := workloadKeys(workloads); got != tt.want {
+				t.Errorf("GroupWorkloads() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// the containers of a workload are grouped into one pod-level recommendation, whatever the case of the controller type
+	workloads, _ := GroupWorkloads(&recos, nil)
+	cart := workloads[NewWorkloadKey("prod", "shop", "deployment", "cart")]
+	if cart == nil || len(cart.Containers) != 2 || cart.Container != "" || cart.Name != "cart" {
+		t.Fatalf("cart = %+v, want a pod with two containers", cart)
+	}
+	if cart.Containers[0].Container != "web" || cart.Containers[0].CurrentCpuRequest != 500 || cart.Containers[1].Container != "sidecar" {
+		t.Errorf("cart containers = %+v", cart.Containers)
+	}
+	// a workload with one container keeps the container's name
+	if db := workloads[NewWorkloadKey("prod", "team-a", "statefulset", "db")]; len(db.Containers) != 1 || db.Container != "db" {
+		t.Errorf("db = %+v, want one container", db)
+	}
+	if got, err := GroupWorkloads(nil, nil); err != nil || len(got) != 0 {
+		t.Errorf("GroupWorkloads(nil) = %v, %v; want no workloads", got, err)
+	}
+}
+
+// returns a client for a kubernetes query, with a server that returns the recommendations for analysis a1
+func workloadsTestClient(t *testing.T, recos string, query *DensifyAPIQuery) *DensifyClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/analysis/containers/kubernetes/a1/results" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, recos)
+	}))
+	t.Cleanup(server.Close)
+	return &DensifyClient{HTTPClient: server.Client(), BaseURL: server.URL, AnalysisIds: []string{"a1"}, Query: query}
+}
+
+// the recommendations in workloadsTestRecommendations, as JSON
+const workloadsTestJSON = `[
+	{"cluster":"prod","namespace":"shop","controllerType":"Deployment","podService":"cart","container":"web","currentCpuRequest":500},
+	{"cluster":"prod","namespace":"shop","controllerType":"deployment","podService":"cart","container":"sidecar","currentCpuRequest":100},
+	{"cluster":"prod","namespace":"team-a","controllerType":"statefulset","podService":"db","container":"db"},
+	{"cluster":"prod","namespace":"team-b","controllerType":"cronjob","podService":"report","container":"job"}
+]`
+
+func TestGetDensifyWorkloads(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  *DensifyAPIQuery
+		filter *DensifyWorkloadFilter
+		want   string
+		hasErr bool
+	}{
+		{"cluster", &DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod"}, nil, "prod/shop/deployment/cart,prod/team-a/statefulset/db,prod/team-b/cronjob/report", false},
+		{"query namespace", &DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod", K8sNamespace: "Team-A"}, nil, "prod/team-a/statefulset/db", false},
+		{"query namespace and filter", &DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod", K8sNamespace: "team-a"}, &DensifyWorkloadFilter{NamespaceGlob: "team-b"}, "", false},
+		{"filter", &DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod"}, &DensifyWorkloadFilter{ControllerTypes: []string{"cronjob", "statefulset"}}, "prod/team-a/statefulset/db,prod/team-b/cronjob/report", false},
+		{"invalid filter", &DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod"}, &DensifyWorkloadFilter{NamespaceGlob: "["}, "", true},
+		{"cloud query", &DensifyAPIQuery{AnalysisTechnology: "aws", AccountNumber: "111"}, nil, "", true},
+		{"no query", nil, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := workloadsTestClient(t, workloadsTestJSON, tt.query)
+			workloads, err := c.GetDensifyWorkloads(tt.filter)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("GetDensifyWorkloads() = %v, want an error", workloadKeys(workloads))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetDensifyWorkloads() error: %v", err)
+			}
+			if got := workloadKeys(workloads); got != tt.want {
+				t.Errorf("GetDensifyWorkloads() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDensifyWorkloadsFallbacks(t *testing.T) {
+	policy := DefaultMaturityPolicy()
+	query := &DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod", FallbackCPURequest: "250m", FallbackMemLimit: "1Gi", MaturityPolicy: &policy}
+	c := workloadsTestClient(t, workloadsTestJSON, query)
+	workloads, err := c.GetDensifyWorkloads(nil)
+	if err != nil {
+		t.Fatalf("GetDensifyWorkloads() error: %v", err)
+	}
+	cart := workloads[NewWorkloadKey("prod", "shop", "deployment", "cart")]
+	if cart == nil || len(cart.Containers) != 2 {
+		t.Fatalf("GetDensifyWorkloads() = %s, want the cart workload with 2 containers", workloadKeys(workloads))
+	}
+	if cart.Containers[0].FallbackCpuRequest != 250 || cart.Containers[0].FallbackMemLimit != 1024 {
+		t.Errorf("Containers[0] fallbacks = %s/%s, want 250m/1Gi", cart.Containers[0].FallbackCpuRequest, cart.Containers[0].FallbackMemLimit)
+	}
+	if cart.Maturity == nil || cart.Containers[0].Maturity == nil {
+		t.Errorf("the maturity policy wasn't applied")
+	}
+
+	// there are no recommendations, so the patch has the fallback values
+	patch, err := cart.GeneratePatch(PatchStrategicMergeYAML, nil)
+	if err != nil {
+		t.Fatalf("GeneratePatch() error: %v", err)
+	}
+	for _, want := range []string{`cpu: "250m"`, `memory: "1Gi"`} {
+		if !strings.Contains(string(patch), want) {
+			t.Errorf("GeneratePatch() has no %s:\n%s", want, patch)
+		}
+	}
+}
+
+func TestSortedWorkloads(t *testing.T) {
+	recos := workloadsTestRecommendations()
+	workloads, _ := GroupWorkloads(&recos, nil)
+	keys, sorted := sortedWorkloads(workloads)
+	if !sort.SliceIsSorted(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() }) {
+		t.Errorf("keys = %v, want them sorted", keys)
+	}
+	for i := 0; i < len(keys); i++ {
+		if sorted[i] != workloads[keys[i]] {
+			t.Errorf("workload %d = %s, want %s", i, sorted[i].WorkloadKey(), keys[i])
+		}
+	}
+}
+
+func TestResolveControllerType(t *testing.T) {
+	recos := []DensifyRecommendation{
+		{Cluster: "prod", Namespace: "shop", ControllerType: "Deployment", PodService: "cart", Container: "web"},
+		{Cluster: "prod", Namespace: "shop", ControllerType: "deployment", PodService: "cart", Container: "sidecar"},
+		{Cluster: "prod", Namespace: "shop", ControllerType: "statefulset", PodService: "db", Container: "db"},
+		{Cluster: "prod", Namespace: "shop", ControllerType: "deployment", PodService: "db", Container: "proxy"},
+		{Cluster: "prod", Namespace: "jobs", ControllerType: "cronjob", PodService: "db", Container: "backup"},
+	}
+	tests := []struct {
+		name      string
+		namespace string
+		podName   string
+		want      string
+		ambiguous []string
+		hasErr    bool
+	}{
+		{"inferred", "shop", "cart", "deployment", nil, false},
+		{"inferred case", "SHOP", " Cart ", "deployment", nil, false},
+		{"other namespace", "jobs", "db", "cronjob", nil, false},
+		{"ambiguous", "shop", "db", "", []string{"deployment", "statefulset"}, true},
+		{"not found", "shop", "api", "", nil, true},
+		{"not in the namespace", "jobs", "cart", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod", K8sNamespace: tt.namespace, K8sPodName: tt.podName}
+			got, err := q.resolveControllerType(&recos)
+			if !tt.hasErr {
+				if err != nil || got != tt.want {
+					t.Errorf("resolveControllerType() = %s, %v; want %s", got, err, tt.want)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("resolveControllerType() = %s, want an error", got)
+			}
+			var ambiguous *AmbiguousControllerTypeError
+			if isAmbiguous := errors.As(err, &ambiguous); isAmbiguous != (tt.ambiguous != nil) {
+				t.Fatalf("resolveControllerType() error = %v, want ambiguous %v", err, tt.ambiguous != nil)
+			}
+			if ambiguous != nil && strings.Join(ambiguous.ControllerTypes, ",") != strings.Join(tt.ambiguous, ",") {
+				t.Errorf("ControllerTypes = %v, want %v", ambiguous.ControllerTypes, tt.ambiguous)
+			}
+		})
+	}
+}
+
+func TestGetDensifyRecommendationControllerType(t *testing.T) {
+	recos := `[
+		{"cluster":"prod","namespace":"shop","controllerType":"deployment","podService":"cart","container":"web"},
+		{"cluster":"prod","namespace":"shop","controllerType":"statefulset","podService":"db","container":"db"},
+		{"cluster":"prod","namespace":"shop","controllerType":"deployment","podService":"db","container":"proxy"}
+	]`
+	tests := []struct {
+		name           string
+		podName        string
+		controllerType string
+		want           string
+		ambiguous      bool
+		hasErr         bool
+	}{
+		{"inferred", "cart", "", "deployment", false, false},
+		{"set in the query", "db", "statefulset", "statefulset", false, false},
+		{"ambiguous", "db", "", "", true, true},
+		{"not found", "api", "", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &DensifyAPIQuery{AnalysisTechnology: "k8s", K8sCluster: "prod", K8sNamespace: "shop", K8sPodName: tt.podName, K8sControllerType: tt.controllerType}
+			reco, err := workloadsTestClient(t, recos, q).GetDensifyRecommendation()
+			if !tt.hasErr {
+				if err != nil || reco.ControllerType != tt.want {
+					t.Errorf("GetDensifyRecommendation() controller type = %s, %v; want %s", reco.ControllerType, err, tt.want)
+				}
+				return
+			}
+			var ambiguous *AmbiguousControllerTypeError
+			if err == nil || errors.As(err, &ambiguous) != tt.ambiguous {
+				t.Errorf("GetDensifyRecommendation() error = %v, want ambiguous %v", err, tt.ambiguous)
+			}
+			if ambiguous != nil && !strings.Contains(ambiguous.Error(), "deployment, statefulset") {
+				t.Errorf("Error() = %s, want the candidate controller types", ambiguous.Error())
+			}
+		})
+	}
+}
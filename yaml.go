@@ -0,0 +1,120 @@
+package densify
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var yamlPlainKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// plain scalars that YAML parsers read as booleans or null rather than strings
+var yamlReservedWords = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true, "y": true, "n": true, "null": true,
+}
+
+// returns the key as a plain scalar, if it's safe to (and the object doesn't quote its keys), otherwise as a double-quoted string
+func (o *exportObject) yamlKey(key string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	if o.quoteKeys || !yamlPlainKeyRegexp.MatchString(key) || yamlReservedWords[strings.ToLower(key)] {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node
+}
+
+// returns a value as a YAML node; strings are always double-quoted, so values like "no" or "1.0" stay strings, and empty objects and
+// lists are written as {} and []
+func yamlNode(value interface{}) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v, Style: yaml.DoubleQuotedStyle}, nil
+	case int64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(v, 10)}, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+		}
+		return yamlNumber(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	case Money:
+		return yamlNumber(v.Amount()), nil
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if len(v) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		for i := 0; i < len(v); i++ {
+			item, err := yamlNode(v[i])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, item)
+		}
+		return node, nil
+	case *exportObject:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if len(v.attributes) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		for i := 0; i < len(v.attributes); i++ {
+			item, err := yamlNode(v.attributes[i].value)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, v.yamlKey(v.attributes[i].key), item)
+		}
+		return node, nil
+	default:
+		return nil, fmt.Errorf("unsupported YAML value type %T", value)
+	}
+}
+
+// returns a number as a plain scalar, tagged as an int if it's a whole number so it's written without a tag
+func yamlNumber(s string) *yaml.Node {
+	if strings.ContainsAny(s, ".eE") {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: s}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: s}
+}
+
+// returns the object as a YAML document in block style, indented by two spaces per level
+func formatYAML(o *exportObject) (string, error) {
+	node, err := yamlNode(o)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	enc := yaml.NewEncoder(&sb)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Exports the recommendations as a YAML mapping keyed by the options' key strategy.
+type YAMLExporter struct {
+	Options ExportOptions
+}
+
+func (e *YAMLExporter) Export(w io.Writer, recos *[]DensifyRecommendation) error {
+	m, err := buildRecommendationExport(recos, &e.Options)
+	if err != nil {
+		return err
+	}
+	doc, err := formatYAML(m)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, doc)
+	return err
+}
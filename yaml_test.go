@@ -0,0 +1,141 @@
+package densify
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAMLExporter(t *testing.T) {
+	recos := hclTestRecommendations()
+	recos[1].Name = "no" // a YAML 1.1 boolean
+	recos[1].CurrentType = "1.0"
+	var buf bytes.Buffer
+	if err := (&YAMLExporter{}).Export(&buf, &recos); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Unmarshal() error: %v\n%s", err, buf.String())
+	}
+	root := doc.Content[0]
+	keys := []string{}
+	for i := 0; i < len(root.Content); i += 2 {
+		keys = append(keys, root.Content[i].Value)
+	}
+	wantKeys := []string{"no", "prod/shop/deployment/cart", "prod/shop/deployment/cart/app", recos[0].Name}
+	if strings.Join(keys, "|") != strings.Join(wantKeys, "|") {
+		t.Errorf("keys = %q, want %q", keys, wantKeys)
+	}
+
+	var out map[string]map[string]interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	tests := []struct {
+		key   string
+		field string
+		want  interface{}
+	}{
+		{recos[0].Name, "name", recos[0].Name},
+		{recos[0].Name, "savingsEstimate", 12.5},
+		{"no", "name", "no"},
+		{"no", "currentType", "1.0"},
+		{"no", "minGroupCurrent", "1"},
+		{"prod/shop/deployment/cart/app", "recommendedCpuRequest", 250},
+	}
+	for _, tt := range tests {
+		if got := out[tt.key][tt.field]; got != tt.want {
+			t.Errorf("%s.%s = %#v, want %#v", tt.key, tt.field, got, tt.want)
+		}
+	}
+	containers, ok := out["prod/shop/deployment/cart"]["containers"].([]interface{})
+	if !ok || len(containers) != 1 || containers[0].(map[string]interface{})["runningHours"] != 720 {
+		t.Errorf("containers = %#v, want one container with 720 running hours", out["prod/shop/deployment/cart"]["containers"])
+	}
+}
+
+func TestYAMLExporterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&YAMLExporter{}).Export(&buf, &[]DensifyRecommendation{}); err != nil || buf.String() != "{}\n" {
+		t.Errorf("Export() = %q, %v; want {}", buf.String(), err)
+	}
+}
+
+func TestYAMLKey(t *testing.T) {
+	tests := []struct {
+		key       string
+		quoteKeys bool
+		want      string
+	}{
+		{"name", false, "name:"},
+		{"yes", false, `"yes":`},
+		{"Off", false, `"Off":`},
+		{"a b", false, `"a b":`},
+		{"name", true, `"name":`},
+	}
+	for _, tt := range tests {
+		o := &exportObject{quoteKeys: tt.quoteKeys}
+		o.set(tt.key, int64(1))
+		doc, err := formatYAML(o)
+		if err != nil {
+			t.Fatalf("formatYAML() error: %v", err)
+		}
+		if want := tt.want + " 1\n"; doc != want {
+			t.Errorf("key %s = %q, want %q", tt.key, doc, want)
+		}
+	}
+}
+
+func TestFormatYAML(t *testing.T) {
+	nested := &exportObject{}
+	nested.set("cpu", "250m")
+	nested.set("ratio", 0.5)
+	nested.set("whole", float64(2))
+	nested.set("nan", math.NaN())
+	nested.set("cost", MoneyFromFloat(12.5, "", PeriodMonthly))
+	nested.set("enabled", true)
+	nested.set("empty", &exportObject{})
+	nested.set("none", []interface{}{})
+	o := &exportObject{}
+	o.set("web", nested)
+	o.set("items", []interface{}{"a", int64(1), &exportObject{}, nested.child("object")})
+	nested.child("object").set("name", "no")
+	want := `web:
+  cpu: "250m"
+  ratio: 0.5
+  whole: 2
+  nan: null
+  cost: 12.50
+  enabled: true
+  empty: {}
+  none: []
+  object:
+    name: "no"
+items:
+  - "a"
+  - 1
+  - {}
+  - name: "no"
+`
+	doc, err := formatYAML(o)
+	if err != nil {
+		t.Fatalf("formatYAML() error: %v", err)
+	}
+	if doc != want {
+		t.Errorf("formatYAML() =\n%s\nwant\n%s", doc, want)
+	}
+	if doc, err := formatYAML(&exportObject{}); err != nil || doc != "{}\n" {
+		t.Errorf("formatYAML() of an empty object = %q, %v; want {}", doc, err)
+	}
+
+	unsupported := &exportObject{}
+	unsupported.set("values", []interface{}{map[string]string{"a": "b"}})
+	if doc, err := formatYAML(unsupported); err == nil {
+		t.Errorf("formatYAML() = %s, want an error for an unsupported type", doc)
+	}
+}